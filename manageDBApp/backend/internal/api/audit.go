@@ -0,0 +1,135 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"mcp-db/internal/audit"
+	"mcp-db/internal/auth"
+	"mcp-db/pkg/types"
+	"net/http"
+	"time"
+)
+
+// auditedOperations change a cluster or read its credentials.
+var auditedOperations = map[string]bool{
+	"create_database":         true,
+	"delete_database":         true,
+	"update_database":         true,
+	"expose_database":         true,
+	"rotate_credentials":      true,
+	"get_database_connection": true,
+	"register_kubeconfig":     true,
+	"revoke_kubeconfig":       true,
+	"reload_engines":          true,
+}
+
+// maxAuditedError bounds the response body kept to report a failure.
+const maxAuditedError = 4096
+
+// auditRecorder captures the status of a response, and its body when it
+// is an error.
+type auditRecorder struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (r *auditRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *auditRecorder) Write(data []byte) (int, error) {
+	if r.status >= http.StatusBadRequest && r.body.Len() < maxAuditedError {
+		r.body.Write(data[:min(len(data), maxAuditedError-r.body.Len())])
+	}
+	return r.ResponseWriter.Write(data)
+}
+
+// audit wraps the handler of an audited operation to record an event with
+// the caller, the target cluster, the redacted parameters, the outcome and
+// the duration. Other operations are returned unchanged.
+func (s *Server) audit(operation string, handler http.HandlerFunc) http.HandlerFunc {
+	if s.auditLog == nil || !auditedOperations[operation] {
+		return handler
+	}
+	return func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			respondWithError(w, http.StatusBadRequest, "Invalid request format")
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+		recorder := &auditRecorder{ResponseWriter: w, status: http.StatusOK}
+		handler(recorder, r)
+
+		event := types.AuditEvent{
+			Time:       start.UTC().Format(time.RFC3339Nano),
+			Operation:  operation,
+			Status:     recorder.status,
+			DurationMs: time.Since(start).Milliseconds(),
+		}
+		if identity, ok := auth.IdentityFrom(r.Context()); ok {
+			event.Identity, event.Groups = identity.Name, identity.Groups
+		}
+		var params map[string]interface{}
+		if json.Unmarshal(body, &params) == nil {
+			event.Params = audit.Redact(params)
+			namespace, _ := params["namespace"].(string)
+			name, _ := params["name"].(string)
+			if name != "" {
+				event.Target = namespace + "/" + name
+			}
+		}
+		switch {
+		case recorder.status == http.StatusUnauthorized || recorder.status == http.StatusForbidden:
+			event.Outcome = audit.OutcomeDenied
+		case recorder.status >= http.StatusBadRequest:
+			event.Outcome = audit.OutcomeFailure
+		default:
+			event.Outcome = audit.OutcomeSuccess
+		}
+		if event.Outcome != audit.OutcomeSuccess {
+			var response types.Response
+			if json.Unmarshal(recorder.body.Bytes(), &response) == nil {
+				event.Error = response.Message
+			}
+		}
+		s.auditLog.Record(event)
+	}
+}
+
+// ListAuditEvents returns the recent audit events, newest first.
+func (s *Server) ListAuditEvents(w http.ResponseWriter, r *http.Request) {
+	var req types.ListAuditEventsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request format")
+		return
+	}
+	filter := audit.Filter{
+		Operation: req.Operation,
+		Identity:  req.Identity,
+		Target:    req.Target,
+		Outcome:   req.Outcome,
+		Limit:     req.Limit,
+	}
+	if req.Since != "" {
+		since, err := time.Parse(time.RFC3339, req.Since)
+		if err != nil {
+			respondWithError(w, http.StatusBadRequest, "since must be an RFC 3339 time")
+			return
+		}
+		filter.Since = since
+	}
+	events := []types.AuditEvent{}
+	if s.auditLog != nil {
+		events = s.auditLog.Recent(filter)
+	}
+	respondWithJSON(w, http.StatusOK, types.Response{
+		Success: true,
+		Message: "Successfully listed audit events",
+		Data:    events,
+	})
+}
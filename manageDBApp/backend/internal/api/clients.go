@@ -0,0 +1,87 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"k8s.io/client-go/rest"
+	"mcp-db/internal/auth"
+	"mcp-db/internal/k8s"
+	"net/http"
+	"sort"
+	"strconv"
+)
+
+// errNotKubernetesUser is returned when a caller without a kubeconfig was
+// not authenticated as a Kubernetes user and so cannot be impersonated.
+var errNotKubernetesUser = errors.New("only callers authenticated with a Kubernetes token can act in this cluster; send a kubeconfig or kubeconfig_ref")
+
+// client returns the Kubernetes client of a request. A kubeconfig, inline
+// or registered, selects the target cluster. Without one, in in-cluster
+// mode the service account of the service acts as the authenticated caller
+// through impersonation. Only identities established by TokenReview are
+// impersonated: API key and JWT identities name themselves and could claim
+// any Kubernetes user or group. Clients are cached per kubeconfig or
+// identity and must stay local to the request.
+func (s *Server) client(r *http.Request, kubeconfig, ref string) (*k8s.Client, error) {
+	if kubeconfig == "" && ref == "" && s.inCluster != nil {
+		identity, ok := auth.IdentityFrom(r.Context())
+		if !ok {
+			return nil, auth.ErrUnauthenticated
+		}
+		if identity.Source != auth.SourceTokenReview {
+			return nil, errNotKubernetesUser
+		}
+		key := identityFingerprint(identity)
+		return s.clients.Get(key, func() (*k8s.Client, error) {
+			return k8s.NewImpersonatingClient(s.inCluster, rest.ImpersonationConfig{
+				UserName: identity.Name,
+				UID:      identity.UID,
+				Groups:   identity.Groups,
+				Extra:    identity.Extra,
+			})
+		})
+	}
+	kubeconfig, err := s.kubeconfig(r.Context(), kubeconfig, ref)
+	if err != nil {
+		return nil, err
+	}
+	client, err := s.clients.Get(k8s.Fingerprint("kubeconfig", kubeconfig), func() (*k8s.Client, error) {
+		return k8s.NewClient(kubeconfig)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("invalid kubeconfig: %w", err)
+	}
+	return client, nil
+}
+
+// identityFingerprint keys impersonating clients by everything that is
+// impersonated, so callers sharing a name but not groups never share one.
+// Lists are prefixed with their length so different identities cannot
+// flatten to the same parts.
+func identityFingerprint(identity *auth.Identity) string {
+	parts := []string{"impersonate", identity.Name, identity.UID, strconv.Itoa(len(identity.Groups))}
+	parts = append(parts, identity.Groups...)
+	keys := make([]string, 0, len(identity.Extra))
+	for key := range identity.Extra {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		parts = append(parts, key, strconv.Itoa(len(identity.Extra[key])))
+		parts = append(parts, identity.Extra[key]...)
+	}
+	return k8s.Fingerprint(parts...)
+}
+
+// respondWithClientError reports an error of client.
+func respondWithClientError(w http.ResponseWriter, err error) {
+	if errors.Is(err, auth.ErrUnauthenticated) {
+		auth.Unauthorized(w, "Authentication required: send a Kubernetes bearer token, a kubeconfig or a kubeconfig_ref")
+		return
+	}
+	if errors.Is(err, errNotKubernetesUser) || errors.Is(err, k8s.ErrKubeconfigNotOwned) {
+		respondWithError(w, http.StatusForbidden, err.Error())
+		return
+	}
+	respondWithError(w, http.StatusBadRequest, err.Error())
+}
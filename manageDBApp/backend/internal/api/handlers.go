@@ -1,11 +1,11 @@
 package api
 
 import (
-	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
-	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-	"log"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"log/slog"
 	"mcp-db/internal/k8s"
 	"mcp-db/pkg/types"
 	"net/http"
@@ -34,9 +34,36 @@ func (s *Server) CreateDatabase(w http.ResponseWriter, r *http.Request) {
 	}
 	if req.Type == "" {
 		respondWithError(w, http.StatusBadRequest, "Database type is required")
+		return
 	}
 	if req.Namespace == "" {
 		respondWithError(w, http.StatusBadRequest, "Namespace is required")
+		return
+	}
+	if err := k8s.ValidateScheduling(&req); err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if engine, ok := k8s.LookupEngine(req.Type); ok {
+		if _, err := k8s.ResolveTopology(engine, req.Topology, req.Replicas, req.Shards); err != nil {
+			respondWithError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		if err := k8s.ValidateVolumes(&req, engine); err != nil {
+			respondWithError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+	}
+	if engine, ok := k8s.LookupEngine(req.Type); ok && engine.Resources != nil {
+		if req.CPULimit == "" {
+			req.CPULimit = engine.Resources.CPU
+		}
+		if req.MemoryLimit == "" {
+			req.MemoryLimit = engine.Resources.Memory
+		}
+		if req.Storage == "" {
+			req.Storage = engine.Resources.Storage
+		}
 	}
 	if req.CPULimit == "" {
 		req.CPULimit = DefaultCPULimit
@@ -47,6 +74,13 @@ func (s *Server) CreateDatabase(w http.ResponseWriter, r *http.Request) {
 	if req.Storage == "" {
 		req.Storage = DefaultStorage
 	}
+	if req.TerminationPolicy == "" {
+		req.TerminationPolicy = k8s.DefaultTerminationPolicy
+	}
+	if !k8s.ValidTerminationPolicy(req.TerminationPolicy) {
+		respondWithError(w, http.StatusBadRequest, fmt.Sprintf("Termination policy must be one of %s", strings.Join(k8s.TerminationPolicies, ", ")))
+		return
+	}
 	// Request values derived from limit
 	if req.CPURequest == "" {
 		req.CPURequest = ratioToRequest(req.CPULimit, CPURequestRatio)
@@ -54,26 +88,59 @@ func (s *Server) CreateDatabase(w http.ResponseWriter, r *http.Request) {
 	if req.MemoryRequest == "" {
 		req.MemoryRequest = ratioToRequest(req.MemoryLimit, MemoryRequestRatio)
 	}
-	if req.Kubeconfig == "" {
-		respondWithError(w, http.StatusBadRequest, "Kubeconfig is required")
+	client, err := s.client(r, req.Kubeconfig, req.KubeconfigRef)
+	if err != nil {
+		respondWithClientError(w, err)
 		return
 	}
-	var err error
-	s.k8sClient, err = k8s.NewClient(req.Kubeconfig)
+	ctx := r.Context()
+	cluster, created, err := client.CreateDatabaseCluster(ctx, &req)
 	if err != nil {
-		respondWithError(w, http.StatusBadRequest, "Kubeconfig is error: "+err.Error())
+		var conflict *k8s.SpecConflictError
+		if errors.As(err, &conflict) {
+			respondWithJSON(w, http.StatusConflict, types.Response{
+				Success: false,
+				Message: fmt.Sprintf("Database cluster '%s' already exists with a different spec", req.Name),
+				Data:    conflict.Diff,
+			})
+			return
+		}
+		var rbacConflict *k8s.RBACConflictError
+		if errors.As(err, &rbacConflict) {
+			respondWithError(w, http.StatusConflict, err.Error())
+			return
+		}
+		var invalid *k8s.InvalidRequestError
+		if errors.As(err, &invalid) {
+			respondWithError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		var unknownVersion *k8s.UnknownVersionError
+		if errors.As(err, &unknownVersion) {
+			respondWithJSON(w, http.StatusBadRequest, types.Response{
+				Success: false,
+				Message: err.Error(),
+				Data:    unknownVersion.Valid,
+			})
+			return
+		}
+		slog.ErrorContext(r.Context(), "Failed to create database cluster", "namespace", req.Namespace, "name", req.Name, "error", err)
+		respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to create database cluster: %v", err))
 		return
 	}
-	ctx := context.Background()
-	if err := s.k8sClient.CreateDatabaseCluster(ctx, &req); err != nil {
-		log.Printf("Failed to create database cluster: %v", err)
-		respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to create database cluster: %v", err))
+	if !created {
+		respondWithJSON(w, http.StatusOK, types.Response{
+			Success: true,
+			Message: fmt.Sprintf("Database cluster '%s' already exists", req.Name),
+			Data:    cluster,
+		})
 		return
 	}
-	log.Println("Created database cluster successfully")
+	slog.InfoContext(r.Context(), "Created database cluster", "namespace", req.Namespace, "name", req.Name)
 	respondWithJSON(w, http.StatusCreated, types.Response{
 		Success: true,
 		Message: fmt.Sprintf("Successfully created database cluster '%s'", req.Name),
+		Data:    cluster,
 	})
 }
 
@@ -85,24 +152,23 @@ func (s *Server) ListDatabases(w http.ResponseWriter, r *http.Request) {
 	}
 	if req.Namespace == "" {
 		respondWithError(w, http.StatusBadRequest, "Not Found namespace")
-	}
-	if req.Kubeconfig == "" {
-		respondWithError(w, http.StatusBadRequest, "Kubeconfig is required")
 		return
 	}
-	var err error
-	s.k8sClient, err = k8s.NewClient(req.Kubeconfig)
+	client, err := s.client(r, req.Kubeconfig, req.KubeconfigRef)
 	if err != nil {
-		respondWithError(w, http.StatusBadRequest, "kubectl is error: "+err.Error())
+		respondWithClientError(w, err)
 		return
 	}
-	clusters, err := s.k8sClient.ListDatabaseClusters(req.Namespace)
+	clusters, err := client.ListDatabaseClusters(r.Context(), req.Namespace)
 	if err != nil {
-		log.Printf("Failed to get database clusters: %v", err)
+		slog.ErrorContext(r.Context(), "Failed to list database clusters", "namespace", req.Namespace, "error", err)
 		respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to get database clusters: %v", err))
 		return
 	}
-	log.Println("List of database clusters successfully")
+	if req.Type != "" {
+		clusters = filterClustersByType(clusters, req.Type)
+	}
+	slog.DebugContext(r.Context(), "Listed database clusters", "namespace", req.Namespace, "count", len(clusters))
 	respondWithJSON(w, http.StatusOK, types.Response{
 		Success: true,
 		Message: fmt.Sprintf("Found %d database clusters in namespace '%s'", len(clusters), req.Namespace),
@@ -110,6 +176,22 @@ func (s *Server) ListDatabases(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+// filterClustersByType keeps the clusters of dbType, a registry type such as
+// mysql or the name of a ClusterDefinition such as apecloud-mysql.
+func filterClustersByType(clusters []types.DBClusterInfo, dbType string) []types.DBClusterInfo {
+	definition := dbType
+	if engine, ok := k8s.LookupEngine(dbType); ok {
+		definition = engine.Definition
+	}
+	filtered := make([]types.DBClusterInfo, 0, len(clusters))
+	for _, cluster := range clusters {
+		if cluster.Type == definition || cluster.Type == dbType {
+			filtered = append(filtered, cluster)
+		}
+	}
+	return filtered
+}
+
 func (s *Server) DeleteDatabase(w http.ResponseWriter, r *http.Request) {
 	var req types.DeleteDatabaseRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
@@ -120,29 +202,167 @@ func (s *Server) DeleteDatabase(w http.ResponseWriter, r *http.Request) {
 		respondWithError(w, http.StatusBadRequest, "Database name is required")
 		return
 	}
-	ctx := context.Background()
-	if req.Kubeconfig == "" {
-		respondWithError(w, http.StatusBadRequest, "KubeConfig is required")
+	ctx := r.Context()
+	client, err := s.client(r, req.Kubeconfig, req.KubeconfigRef)
+	if err != nil {
+		respondWithClientError(w, err)
+		return
+	}
+	policy, err := client.GetTerminationPolicy(ctx, req.Name, req.Namespace)
+	if apierrors.IsNotFound(err) {
+		respondWithError(w, http.StatusNotFound, fmt.Sprintf("Database cluster '%s' not found", req.Name))
 		return
 	}
-	var err error
-	s.k8sClient, err = k8s.NewClient(req.Kubeconfig)
 	if err != nil {
-		respondWithError(w, http.StatusBadRequest, "KubeConfig is error: "+err.Error())
+		slog.ErrorContext(r.Context(), "Failed to get database cluster", "namespace", req.Namespace, "name", req.Name, "error", err)
+		respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to get database cluster: %v", err))
+		return
+	}
+	if policy == k8s.TerminationPolicyDoNotTerminate {
+		respondWithError(w, http.StatusForbidden, fmt.Sprintf("Database cluster '%s' is protected by termination policy %s; update the policy before deleting it", req.Name, policy))
+		return
+	}
+	if k8s.TerminationPolicyRemovesData(policy) && req.Confirm != req.Name {
+		respondWithError(w, http.StatusBadRequest, fmt.Sprintf("Deleting database cluster '%s' with termination policy %s removes its data; set confirm to the cluster name to proceed", req.Name, policy))
 		return
 	}
-	if err := s.k8sClient.DeleteDatabaseCluster(ctx, req.Name, req.Namespace); err != nil {
-		log.Printf("Failed to delete database cluster: %v", err)
+	if err := client.DeleteDatabaseCluster(ctx, req.Name, req.Namespace); err != nil {
+		slog.ErrorContext(r.Context(), "Failed to delete database cluster", "namespace", req.Namespace, "name", req.Name, "error", err)
 		respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to delete database cluster: %v", err))
 		return
 	}
-	log.Println("Deleted database cluster successfully")
+	slog.InfoContext(r.Context(), "Deleted database cluster", "namespace", req.Namespace, "name", req.Name)
 	respondWithJSON(w, http.StatusOK, types.Response{
 		Success: true,
 		Message: fmt.Sprintf("Successfully deleted database cluster '%s'", req.Name),
 	})
 }
 
+func (s *Server) ListEngines(w http.ResponseWriter, r *http.Request) {
+	var req types.ListEnginesRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request format")
+		return
+	}
+	client, err := s.client(r, req.Kubeconfig, req.KubeconfigRef)
+	if err != nil {
+		respondWithClientError(w, err)
+		return
+	}
+	engines, err := client.ListEngines(r.Context())
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Failed to list engines", "error", err)
+		respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to list engines: %v", err))
+		return
+	}
+	respondWithJSON(w, http.StatusOK, types.Response{
+		Success: true,
+		Message: fmt.Sprintf("Found %d engines", len(engines)),
+		Data:    engines,
+	})
+}
+
+func (s *Server) ReloadEngines(w http.ResponseWriter, r *http.Request) {
+	if err := k8s.ReloadEngineFile(); err != nil {
+		slog.ErrorContext(r.Context(), "Failed to reload engines", "error", err)
+		respondWithError(w, http.StatusBadRequest, fmt.Sprintf("Failed to reload engines: %v", err))
+		return
+	}
+	respondWithJSON(w, http.StatusOK, types.Response{
+		Success: true,
+		Message: fmt.Sprintf("Reloaded %d engines", len(k8s.Engines())),
+	})
+}
+
+func (s *Server) GarbageReport(w http.ResponseWriter, r *http.Request) {
+	var req types.ListDatabasesRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request format")
+		return
+	}
+	if req.Namespace == "" {
+		respondWithError(w, http.StatusBadRequest, "Namespace is required")
+		return
+	}
+	client, err := s.client(r, req.Kubeconfig, req.KubeconfigRef)
+	if err != nil {
+		respondWithClientError(w, err)
+		return
+	}
+	report, err := client.FindOrphanedRBAC(r.Context(), req.Namespace)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Failed to find orphaned RBAC objects", "namespace", req.Namespace, "error", err)
+		respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to find orphaned RBAC objects: %v", err))
+		return
+	}
+	total := len(report.ServiceAccounts) + len(report.Roles) + len(report.RoleBindings)
+	respondWithJSON(w, http.StatusOK, types.Response{
+		Success: true,
+		Message: fmt.Sprintf("Found %d orphaned RBAC objects in namespace '%s'", total, req.Namespace),
+		Data:    report,
+	})
+}
+
+func (s *Server) UpdateDatabase(w http.ResponseWriter, r *http.Request) {
+	var req types.UpdateDatabaseRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request format")
+		return
+	}
+	if req.Name == "" {
+		respondWithError(w, http.StatusBadRequest, "Database name is required")
+		return
+	}
+	if !k8s.ValidTerminationPolicy(req.TerminationPolicy) {
+		respondWithError(w, http.StatusBadRequest, fmt.Sprintf("Termination policy must be one of %s", strings.Join(k8s.TerminationPolicies, ", ")))
+		return
+	}
+	client, err := s.client(r, req.Kubeconfig, req.KubeconfigRef)
+	if err != nil {
+		respondWithClientError(w, err)
+		return
+	}
+	ctx := r.Context()
+	if err := client.UpdateTerminationPolicy(ctx, req.Name, req.Namespace, req.TerminationPolicy); err != nil {
+		slog.ErrorContext(r.Context(), "Failed to update database cluster", "namespace", req.Namespace, "name", req.Name, "error", err)
+		respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to update database cluster: %v", err))
+		return
+	}
+	slog.InfoContext(r.Context(), "Updated database cluster", "namespace", req.Namespace, "name", req.Name, "termination_policy", req.TerminationPolicy)
+	respondWithJSON(w, http.StatusOK, types.Response{
+		Success: true,
+		Message: fmt.Sprintf("Successfully set termination policy of database cluster '%s' to %s", req.Name, req.TerminationPolicy),
+	})
+}
+
+func (s *Server) GetDatabase(w http.ResponseWriter, r *http.Request) {
+	var req types.GetDatabasesRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request format")
+		return
+	}
+	if req.Name == "" {
+		respondWithError(w, http.StatusBadRequest, "Database name is required")
+		return
+	}
+	client, err := s.client(r, req.Kubeconfig, req.KubeconfigRef)
+	if err != nil {
+		respondWithClientError(w, err)
+		return
+	}
+	cluster, err := client.GetDatabaseCluster(r.Context(), req.Name, req.Namespace)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Failed to get database cluster", "namespace", req.Namespace, "name", req.Name, "error", err)
+		respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to get database cluster: %v", err))
+		return
+	}
+	respondWithJSON(w, http.StatusOK, types.Response{
+		Success: true,
+		Message: fmt.Sprintf("Found database cluster '%s'", req.Name),
+		Data:    cluster,
+	})
+}
+
 func (s *Server) GetDatabaseConn(w http.ResponseWriter, r *http.Request) {
 	var req types.GetDatabasesRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
@@ -151,51 +371,150 @@ func (s *Server) GetDatabaseConn(w http.ResponseWriter, r *http.Request) {
 	}
 	if req.Namespace == "" {
 		respondWithError(w, http.StatusBadRequest, "Namespace is required")
+		return
 	}
 	if req.Name == "" {
 		respondWithError(w, http.StatusBadRequest, "Database name is required")
-	}
-	if req.Kubeconfig == "" {
-		respondWithError(w, http.StatusBadRequest, "KubeConfig is required")
 		return
 	}
-	var err error
-	s.k8sClient, err = k8s.NewClient(req.Kubeconfig)
+	client, err := s.client(r, req.Kubeconfig, req.KubeconfigRef)
 	if err != nil {
-		respondWithError(w, http.StatusBadRequest, "KubeConfig is error: "+err.Error())
+		respondWithClientError(w, err)
 		return
 	}
-	secretName := fmt.Sprintf("%s-conn-credential", req.Name)
-	secret, err := s.k8sClient.ClientSet.CoreV1().Secrets(req.Namespace).Get(context.TODO(), secretName, metav1.GetOptions{})
+	creds, err := client.GetConnectionCredentials(r.Context(), req.Name, req.Namespace)
 	if err != nil {
 		respondWithError(w, http.StatusBadRequest, "database is not exist.")
-		log.Printf("Failed to get database connection secret: %v", err)
+		slog.ErrorContext(r.Context(), "Failed to get database connection credentials", "namespace", req.Namespace, "name", req.Name, "error", err)
 		return
 	}
 
 	var res types.DatabasesResponse
-	var dbType string
-	for key, value := range secret.Data {
-		if key == "username" {
-			res.Username = string(value)
-		}
-		if key == "password" {
-			res.Password = string(value)
+	res.Type = creds.Type
+	res.Username = creds.Username
+	res.Password = creds.Password
+	res.Address = fmt.Sprintf("%s.%s.svc", creds.Service, req.Namespace)
+	res.Port = creds.Port
+	conn := k8s.BuildConnection(res.Type, res.Address, res.Port, res.Username, res.Password)
+	res.Dsn = conn.URI
+	res.Database = conn.Database
+	res.JdbcUrl = conn.JDBCURL
+	res.Cli = conn.CLI
+	// External access is optional; the in-cluster connection is returned
+	// even when the external Service cannot be read.
+	res.External, err = client.GetExternalAccess(r.Context(), req.Name, req.Namespace)
+	if err != nil {
+		slog.WarnContext(r.Context(), "Failed to get external access, omitting it", "namespace", req.Namespace, "name", req.Name, "error", err)
+		res.External = nil
+	}
+	if res.External != nil && res.External.Status == "Ready" {
+		external := k8s.BuildConnection(res.Type, res.External.Host, res.External.Port, res.Username, res.Password)
+		res.ExternalDsn = external.URI
+		res.External.JdbcUrl = external.JDBCURL
+		res.External.Cli = external.CLI
+	}
+	slog.InfoContext(r.Context(), "Read database connection", "namespace", req.Namespace, "name", req.Name)
+	respondWithJSON(w, http.StatusOK, types.Response{
+		Success: true,
+		Message: fmt.Sprintf("Found database connect clusters in namespace '%s'", req.Namespace),
+		Data:    res,
+	})
+}
+
+func (s *Server) ExposeDatabase(w http.ResponseWriter, r *http.Request) {
+	var req types.ExposeDatabaseRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request format")
+		return
+	}
+	if req.Name == "" {
+		respondWithError(w, http.StatusBadRequest, "Database name is required")
+		return
+	}
+	if req.Namespace == "" {
+		respondWithError(w, http.StatusBadRequest, "Namespace is required")
+		return
+	}
+	if req.ServiceType == "" {
+		req.ServiceType = k8s.ExternalServiceTypes[0]
+	}
+	if req.Enable && !k8s.ValidExternalServiceType(req.ServiceType) {
+		respondWithError(w, http.StatusBadRequest, fmt.Sprintf("Service type must be one of %s", strings.Join(k8s.ExternalServiceTypes, ", ")))
+		return
+	}
+	client, err := s.client(r, req.Kubeconfig, req.KubeconfigRef)
+	if err != nil {
+		respondWithClientError(w, err)
+		return
+	}
+	ctx := r.Context()
+	if !req.Enable {
+		if err := client.DisableExternalAccess(ctx, req.Name, req.Namespace); err != nil {
+			slog.ErrorContext(r.Context(), "Failed to disable external access", "namespace", req.Namespace, "name", req.Name, "error", err)
+			respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to disable external access: %v", err))
+			return
 		}
-		if key == "host" {
-			dbType = strings.SplitN(string(value), "-", 2)[1]
-			res.Address = fmt.Sprintf("%s.%s.svc", string(value), req.Namespace)
+		slog.InfoContext(r.Context(), "Disabled external access", "namespace", req.Namespace, "name", req.Name)
+		respondWithJSON(w, http.StatusOK, types.Response{
+			Success: true,
+			Message: fmt.Sprintf("Disabled external access to database cluster '%s'", req.Name),
+		})
+		return
+	}
+	access, err := client.EnableExternalAccess(ctx, req.Name, req.Namespace, req.ServiceType)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Failed to enable external access", "namespace", req.Namespace, "name", req.Name, "error", err)
+		var invalid *k8s.InvalidRequestError
+		if errors.As(err, &invalid) {
+			respondWithError(w, http.StatusBadRequest, err.Error())
+			return
 		}
-		if key == "port" {
-			res.Port = string(value)
+		respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to enable external access: %v", err))
+		return
+	}
+	slog.InfoContext(r.Context(), "Enabled external access", "namespace", req.Namespace, "name", req.Name, "service_type", req.ServiceType)
+	respondWithJSON(w, http.StatusOK, types.Response{
+		Success: true,
+		Message: fmt.Sprintf("Exposed database cluster '%s' via %s service", req.Name, access.ServiceType),
+		Data:    access,
+	})
+}
+
+func (s *Server) RotateCredentials(w http.ResponseWriter, r *http.Request) {
+	var req types.RotateCredentialsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request format")
+		return
+	}
+	if req.Name == "" {
+		respondWithError(w, http.StatusBadRequest, "Database name is required")
+		return
+	}
+	if req.Namespace == "" {
+		respondWithError(w, http.StatusBadRequest, "Namespace is required")
+		return
+	}
+	client, err := s.client(r, req.Kubeconfig, req.KubeconfigRef)
+	if err != nil {
+		respondWithClientError(w, err)
+		return
+	}
+	rotation, err := client.RotateCredentials(r.Context(), req.Name, req.Namespace)
+	if err != nil {
+		var invalid *k8s.InvalidRequestError
+		if errors.As(err, &invalid) {
+			respondWithError(w, http.StatusBadRequest, err.Error())
+			return
 		}
+		slog.ErrorContext(r.Context(), "Failed to rotate credentials", "namespace", req.Namespace, "name", req.Name, "error", err)
+		respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to rotate credentials: %v", err))
+		return
 	}
-	var dsn = fmt.Sprintf("%s://%s:%s@%s:%s", dbType, res.Username, res.Password, res.Address, res.Port)
-	log.Println("found database connection successfully")
+	slog.InfoContext(r.Context(), "Rotated credentials", "namespace", req.Namespace, "name", req.Name, "restarted_components", rotation.RestartedComponents)
 	respondWithJSON(w, http.StatusOK, types.Response{
 		Success: true,
-		Message: fmt.Sprintf("Found database connect clusters in namespace '%s'", req.Namespace),
-		Data:    dsn,
+		Message: fmt.Sprintf("Rotated credentials of database cluster '%s'", req.Name),
+		Data:    rotation,
 	})
 }
 
@@ -204,7 +523,7 @@ func respondWithJSON(w http.ResponseWriter, code int, payload interface{}) {
 	w.WriteHeader(code)
 
 	if err := json.NewEncoder(w).Encode(payload); err != nil {
-		log.Printf("Error encoding response: %v", err)
+		slog.Error("Failed to encode response", "error", err)
 	}
 }
 
@@ -0,0 +1,33 @@
+package api
+
+import (
+	"mcp-db/pkg/types"
+	"reflect"
+	"testing"
+)
+
+func TestFilterClustersByType(t *testing.T) {
+	clusters := []types.DBClusterInfo{
+		{Name: "orders", Type: "apecloud-mysql"},
+		{Name: "users", Type: "postgresql"},
+		{Name: "cache", Type: "redis"},
+	}
+	tests := []struct {
+		dbType string
+		want   []string
+	}{
+		{dbType: "mysql", want: []string{"orders"}},
+		{dbType: "apecloud-mysql", want: []string{"orders"}},
+		{dbType: "postgresql", want: []string{"users"}},
+		{dbType: "mongodb", want: nil},
+	}
+	for _, tt := range tests {
+		var got []string
+		for _, cluster := range filterClustersByType(clusters, tt.dbType) {
+			got = append(got, cluster.Name)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("filterClustersByType(%q) = %v, want %v", tt.dbType, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,69 @@
+package api
+
+import (
+	"context"
+	"mcp-db/internal/k8s"
+	"mcp-db/pkg/types"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// readinessTimeout bounds each readiness check.
+const readinessTimeout = 5 * time.Second
+
+// ReadinessCheck reports whether a dependency of the server is usable.
+type ReadinessCheck func(ctx context.Context) error
+
+func checkEngines(ctx context.Context) error {
+	return k8s.ValidateDatabaseConfigs()
+}
+
+// Healthz reports that the process is alive.
+func (s *Server) Healthz(w http.ResponseWriter, r *http.Request) {
+	respondWithJSON(w, http.StatusOK, types.Response{Success: true, Message: "ok"})
+}
+
+// Readyz runs the readiness checks concurrently and reports 503 with the
+// failing ones, or while the server is shutting down.
+func (s *Server) Readyz(w http.ResponseWriter, r *http.Request) {
+	if s.draining.Load() {
+		respondWithError(w, http.StatusServiceUnavailable, "Shutting down")
+		return
+	}
+	ctx, cancel := context.WithTimeout(r.Context(), readinessTimeout)
+	defer cancel()
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	results := make(map[string]string, len(s.readiness))
+	var failed []string
+	for name, check := range s.readiness {
+		wg.Add(1)
+		go func(name string, check ReadinessCheck) {
+			defer wg.Done()
+			result := "ok"
+			if err := check(ctx); err != nil {
+				result = err.Error()
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			results[name] = result
+			if result != "ok" {
+				failed = append(failed, name)
+			}
+		}(name, check)
+	}
+	wg.Wait()
+	if len(failed) > 0 {
+		sort.Strings(failed)
+		respondWithJSON(w, http.StatusServiceUnavailable, types.Response{
+			Success: false,
+			Message: "Not ready: " + strings.Join(failed, ", "),
+			Data:    results,
+		})
+		return
+	}
+	respondWithJSON(w, http.StatusOK, types.Response{Success: true, Message: "ready", Data: results})
+}
@@ -0,0 +1,113 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"mcp-db/internal/auth"
+	"mcp-db/internal/k8s"
+	"mcp-db/pkg/types"
+	"net/http"
+	"time"
+)
+
+// kubeconfig returns the kubeconfig of a request: the inline one, or the one
+// registered under ref.
+func (s *Server) kubeconfig(ctx context.Context, kubeconfig, ref string) (string, error) {
+	if kubeconfig != "" && ref != "" {
+		return "", errors.New("set either kubeconfig or kubeconfig_ref, not both")
+	}
+	if ref == "" {
+		if kubeconfig == "" {
+			return "", errors.New("kubeconfig or kubeconfig_ref is required")
+		}
+		return kubeconfig, nil
+	}
+	if s.kubeconfigs == nil {
+		return "", errors.New("kubeconfig store is not configured")
+	}
+	stored, err := s.kubeconfigs.Get(ctx, kubeconfigOwner(ctx), ref)
+	if err != nil {
+		return "", err
+	}
+	return stored, nil
+}
+
+// kubeconfigOwner identifies the caller registered kubeconfigs belong to.
+// Identities of different authenticators never share an owner.
+func kubeconfigOwner(ctx context.Context) string {
+	identity, ok := auth.IdentityFrom(ctx)
+	if !ok {
+		return ""
+	}
+	return identity.Source + ":" + identity.Name
+}
+
+func (s *Server) RegisterKubeconfig(w http.ResponseWriter, r *http.Request) {
+	var req types.RegisterKubeconfigRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request format")
+		return
+	}
+	if req.Kubeconfig == "" {
+		respondWithError(w, http.StatusBadRequest, "Kubeconfig is required")
+		return
+	}
+	ttl := k8s.DefaultKubeconfigTTL
+	if req.TTLSeconds != 0 {
+		ttl = time.Duration(req.TTLSeconds) * time.Second
+	}
+	if ttl <= 0 || ttl > k8s.MaxKubeconfigTTL {
+		respondWithError(w, http.StatusBadRequest, fmt.Sprintf("TTL must be between 1 second and %s", k8s.MaxKubeconfigTTL))
+		return
+	}
+	kubeconfig, err := k8s.SelectKubeconfigContext(req.Kubeconfig, req.Context)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	handle, expiresAt, err := s.kubeconfigs.Put(r.Context(), kubeconfigOwner(r.Context()), kubeconfig, ttl)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Failed to register kubeconfig", "error", err)
+		respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to register kubeconfig: %v", err))
+		return
+	}
+	slog.InfoContext(r.Context(), "Registered kubeconfig", "expires_at", expiresAt)
+	respondWithJSON(w, http.StatusCreated, types.Response{
+		Success: true,
+		Message: "Registered kubeconfig",
+		Data: types.KubeconfigHandle{
+			Handle:    handle,
+			ExpiresAt: expiresAt.UTC().Format(time.RFC3339),
+		},
+	})
+}
+
+func (s *Server) RevokeKubeconfig(w http.ResponseWriter, r *http.Request) {
+	var req types.RevokeKubeconfigRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request format")
+		return
+	}
+	if req.Handle == "" {
+		respondWithError(w, http.StatusBadRequest, "Handle is required")
+		return
+	}
+	err := s.kubeconfigs.Delete(r.Context(), kubeconfigOwner(r.Context()), req.Handle)
+	if errors.Is(err, k8s.ErrKubeconfigNotOwned) {
+		respondWithError(w, http.StatusForbidden, err.Error())
+		return
+	}
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Failed to revoke kubeconfig", "error", err)
+		respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to revoke kubeconfig: %v", err))
+		return
+	}
+	slog.InfoContext(r.Context(), "Revoked kubeconfig")
+	respondWithJSON(w, http.StatusOK, types.Response{
+		Success: true,
+		Message: "Revoked kubeconfig",
+	})
+}
@@ -0,0 +1,307 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"mcp-db/internal/audit"
+	"mcp-db/internal/auth"
+	"mcp-db/internal/k8s"
+	"mcp-db/internal/mcp"
+	"mcp-db/pkg/types"
+	"net/http"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// OpenAPIVersion is the version of the API described by /openapi.json.
+const OpenAPIVersion = "1.0.0"
+
+// fieldDoc documents a request field in the OpenAPI specification. Enum is
+// a function so values from the engine registry follow reloads.
+type fieldDoc struct {
+	Description string
+	Enum        func() []string
+	Default     interface{}
+}
+
+// commonFieldDocs document fields shared by most requests.
+var commonFieldDocs = map[string]fieldDoc{
+	"name":           {Description: "Name of the database cluster"},
+	"namespace":      {Description: "Namespace of the database cluster"},
+	"kubeconfig":     {Description: "Kubeconfig of the target Kubernetes cluster"},
+	"kubeconfig_ref": {Description: "Handle of a registered kubeconfig, used instead of kubeconfig"},
+}
+
+// fieldDocs document request fields per type, overriding commonFieldDocs.
+// ValidateOpenAPI checks that they match the structs in pkg/types.
+var fieldDocs = map[reflect.Type]map[string]fieldDoc{
+	reflect.TypeOf(types.CreateDatabaseRequest{}): {
+		"type":               {Description: "Database engine", Enum: engineTypes},
+		"version":            {Description: "Engine version, defaults to the default version of the engine"},
+		"cpu":                {Description: "CPU limit", Default: DefaultCPULimit},
+		"memory":             {Description: "Memory limit", Default: DefaultMemoryLimit},
+		"cpu_request":        {Description: fmt.Sprintf("CPU request, defaults to 1/%d of the limit", CPURequestRatio)},
+		"memory_request":     {Description: fmt.Sprintf("Memory request, defaults to 1/%d of the limit", MemoryRequestRatio)},
+		"storage":            {Description: "Size of the data volume", Default: DefaultStorage},
+		"termination_policy": {Description: "What deleting the cluster removes", Enum: terminationPolicies, Default: k8s.DefaultTerminationPolicy},
+		"topology":           {Description: "Deployment shape, one of the topologies of the engine", Default: k8s.TopologyStandalone},
+		"replicas":           {Description: "Replicas of the main component, defaults to the topology default"},
+		"shards":             {Description: "Shards of a sharded topology, defaults to the topology default"},
+		"node_labels":        {Description: "Labels of the nodes the pods may run on"},
+		"tolerations":        {Description: "Taints the pods tolerate"},
+		"pod_anti_affinity":  {Description: "Whether replicas must run on different topology domains", Enum: constant(k8s.PodAntiAffinityPreferred, k8s.PodAntiAffinityRequired), Default: k8s.PodAntiAffinityPreferred},
+		"tenancy":            {Description: "Whether the pods share nodes with other clusters", Enum: constant(k8s.TenancySharedNode, k8s.TenancyDedicatedNode), Default: k8s.TenancySharedNode},
+		"topology_keys":      {Description: "Node labels spreading the replicas"},
+		"storage_class":      {Description: "StorageClass of the volumes, must allow volume expansion"},
+		"volumes":            {Description: "Extra volumes besides data supported by the engine"},
+	},
+	reflect.TypeOf(types.Toleration{}): {
+		"key":                {Description: "Taint key"},
+		"operator":           {Description: "How the value is matched", Enum: constant("Equal", "Exists"), Default: "Equal"},
+		"value":              {Description: "Taint value"},
+		"effect":             {Description: "Taint effect, empty matches all effects", Enum: constant("NoSchedule", "PreferNoSchedule", "NoExecute")},
+		"toleration_seconds": {Description: "Seconds a NoExecute taint is tolerated"},
+	},
+	reflect.TypeOf(types.VolumeRequest{}): {
+		"name":    {Description: "Volume name"},
+		"storage": {Description: "Volume size"},
+	},
+	reflect.TypeOf(types.ListDatabasesRequest{}): {
+		"type": {Description: "Only list clusters of this type, an engine registry type such as mysql or a ClusterDefinition name such as apecloud-mysql. Not used by the garbage report"},
+	},
+	reflect.TypeOf(types.DeleteDatabaseRequest{}): {
+		"confirm": {Description: "Name of the cluster, required when its termination policy removes data"},
+	},
+	reflect.TypeOf(types.UpdateDatabaseRequest{}): {
+		"termination_policy": {Description: "New termination policy", Enum: terminationPolicies},
+	},
+	reflect.TypeOf(types.ListAuditEventsRequest{}): {
+		"operation": {Description: "Only list events of this operation"},
+		"identity":  {Description: "Only list events of this caller"},
+		"target":    {Description: "Only list events on this target, namespace/name"},
+		"outcome":   {Description: "Only list events with this outcome", Enum: constant(audit.OutcomeSuccess, audit.OutcomeFailure, audit.OutcomeDenied)},
+		"since":     {Description: "Only list events at or after this RFC 3339 time"},
+		"limit":     {Description: "Maximum number of events, all when zero"},
+	},
+	reflect.TypeOf(types.GetDatabasesRequest{}):      {},
+	reflect.TypeOf(types.ListEnginesRequest{}):       {},
+	reflect.TypeOf(types.RotateCredentialsRequest{}): {},
+	reflect.TypeOf(types.RegisterKubeconfigRequest{}): {
+		"context":     {Description: "Context of the kubeconfig to use, defaults to its current context"},
+		"ttl_seconds": {Description: "Seconds until the handle expires", Default: int(k8s.DefaultKubeconfigTTL.Seconds())},
+	},
+	reflect.TypeOf(types.RevokeKubeconfigRequest{}): {
+		"handle": {Description: "Handle returned on registration"},
+	},
+	reflect.TypeOf(types.ExposeDatabaseRequest{}): {
+		"enable":       {Description: "Create the external service when true, remove it when false"},
+		"service_type": {Description: "Type of the external service", Enum: constant(k8s.ExternalServiceTypes...), Default: k8s.ExternalServiceTypes[0]},
+	},
+}
+
+func engineTypes() []string {
+	names := make([]string, 0)
+	for name := range k8s.Engines() {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func terminationPolicies() []string {
+	return k8s.TerminationPolicies
+}
+
+func constant(values ...string) func() []string {
+	return func() []string { return values }
+}
+
+// OpenAPI serves the OpenAPI 3 specification of the route table.
+func (s *Server) OpenAPI(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(s.openAPISpec()); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+func (s *Server) openAPISpec() map[string]interface{} {
+	paths := map[string]interface{}{}
+	for _, rt := range s.routes() {
+		responses := map[string]interface{}{
+			strconv.Itoa(rt.Status): jsonResponse(http.StatusText(rt.Status), responseSchema(rt.Response)),
+		}
+		for _, code := range append(rt.Errors, http.StatusUnauthorized, http.StatusForbidden) {
+			responses[strconv.Itoa(code)] = jsonResponse(http.StatusText(code), responseSchema(nil))
+		}
+		operation := map[string]interface{}{
+			"operationId": strings.ReplaceAll(strings.Trim(rt.Path, "/"), "/", "_"),
+			"summary":     rt.Summary,
+			"responses":   responses,
+		}
+		if rt.Request != nil {
+			operation["requestBody"] = map[string]interface{}{
+				"required": true,
+				"content": map[string]interface{}{
+					"application/json": map[string]interface{}{"schema": requestSchema(rt.Request)},
+				},
+			}
+		}
+		paths[rt.Path] = map[string]interface{}{"post": operation}
+	}
+	return map[string]interface{}{
+		"openapi": "3.0.3",
+		"info": map[string]interface{}{
+			"title":   MCPServerName,
+			"version": OpenAPIVersion,
+		},
+		"paths": paths,
+		"components": map[string]interface{}{
+			"securitySchemes": map[string]interface{}{
+				"bearer": map[string]interface{}{"type": "http", "scheme": "bearer"},
+				"apiKey": map[string]interface{}{"type": "apiKey", "in": "header", "name": auth.APIKeyHeader},
+			},
+		},
+		"security": []interface{}{
+			map[string]interface{}{"bearer": []string{}},
+			map[string]interface{}{"apiKey": []string{}},
+			map[string]interface{}{},
+		},
+	}
+}
+
+func jsonResponse(description string, schema map[string]interface{}) map[string]interface{} {
+	return map[string]interface{}{
+		"description": description,
+		"content": map[string]interface{}{
+			"application/json": map[string]interface{}{"schema": schema},
+		},
+	}
+}
+
+// responseSchema returns the schema of a types.Response whose data field
+// holds a value of the type of data.
+func responseSchema(data interface{}) map[string]interface{} {
+	schema := mcp.SchemaFor(types.Response{})
+	properties := schema["properties"].(map[string]interface{})
+	if data == nil {
+		delete(properties, "data")
+	} else {
+		properties["data"] = mcp.SchemaFor(data)
+	}
+	return schema
+}
+
+// requestSchema returns the schema of a request struct with the
+// descriptions, enums and defaults of fieldDocs.
+func requestSchema(request interface{}) map[string]interface{} {
+	schema := mcp.SchemaFor(request)
+	annotateSchema(schema, reflect.TypeOf(request))
+	return schema
+}
+
+func annotateSchema(schema map[string]interface{}, t reflect.Type) {
+	for t.Kind() == reflect.Pointer || t.Kind() == reflect.Slice {
+		if t.Kind() == reflect.Slice {
+			schema, _ = schema["items"].(map[string]interface{})
+		}
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct || schema == nil {
+		return
+	}
+	properties, _ := schema["properties"].(map[string]interface{})
+	for name, field := range jsonFields(t) {
+		property, ok := properties[name].(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if doc, ok := lookupFieldDoc(t, name); ok {
+			property["description"] = doc.Description
+			if doc.Enum != nil {
+				property["enum"] = doc.Enum()
+			}
+			if doc.Default != nil {
+				property["default"] = doc.Default
+			}
+		}
+		annotateSchema(property, field.Type)
+	}
+}
+
+func lookupFieldDoc(t reflect.Type, name string) (fieldDoc, bool) {
+	if doc, ok := fieldDocs[t][name]; ok {
+		return doc, true
+	}
+	doc, ok := commonFieldDocs[name]
+	return doc, ok
+}
+
+// jsonFields returns the exported fields of a struct by JSON name.
+func jsonFields(t reflect.Type) map[string]reflect.StructField {
+	fields := map[string]reflect.StructField{}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		fields[name] = field
+	}
+	return fields
+}
+
+// ValidateOpenAPI checks that the OpenAPI field documentation and the
+// request structs agree: every request has documentation, every documented
+// field exists and every request field is documented.
+func ValidateOpenAPI() error {
+	var s Server
+	for _, rt := range s.routes() {
+		if rt.Description == "" {
+			return fmt.Errorf("route %s: operation %q has no MCP tool description", rt.Path, rt.Operation)
+		}
+		if rt.Request == nil {
+			continue
+		}
+		if err := validateFieldDocs(reflect.TypeOf(rt.Request)); err != nil {
+			return fmt.Errorf("route %s: %w", rt.Path, err)
+		}
+	}
+	for t, docs := range fieldDocs {
+		fields := jsonFields(t)
+		for name := range docs {
+			if _, ok := fields[name]; !ok {
+				return fmt.Errorf("documented field %s does not exist in %s", name, t.Name())
+			}
+		}
+	}
+	return nil
+}
+
+func validateFieldDocs(t reflect.Type) error {
+	for t.Kind() == reflect.Pointer || t.Kind() == reflect.Slice {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return nil
+	}
+	if _, ok := fieldDocs[t]; !ok {
+		return fmt.Errorf("%s has no field documentation", t.Name())
+	}
+	for name, field := range jsonFields(t) {
+		if _, ok := lookupFieldDoc(t, name); !ok {
+			return fmt.Errorf("field %s of %s is not documented", name, t.Name())
+		}
+		if err := validateFieldDocs(field.Type); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,117 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strconv"
+	"testing"
+)
+
+func TestValidateOpenAPI(t *testing.T) {
+	if err := ValidateOpenAPI(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestValidateOpenAPIDetectsDrift(t *testing.T) {
+	var s Server
+	var request reflect.Type
+	for _, rt := range s.routes() {
+		if rt.Request != nil {
+			request = reflect.TypeOf(rt.Request)
+			break
+		}
+	}
+	docs := fieldDocs[request]
+	defer func() { fieldDocs[request] = docs }()
+
+	stale := map[string]fieldDoc{"no_such_field": {Description: "Stale"}}
+	for name, doc := range docs {
+		stale[name] = doc
+	}
+	fieldDocs[request] = stale
+	if err := ValidateOpenAPI(); err == nil {
+		t.Errorf("documenting a field %s does not have was accepted", request.Name())
+	}
+
+	delete(fieldDocs, request)
+	if err := ValidateOpenAPI(); err == nil {
+		t.Errorf("%s without field documentation was accepted", request.Name())
+	}
+}
+
+func TestOpenAPISpec(t *testing.T) {
+	var s Server
+	recorder := httptest.NewRecorder()
+	s.OpenAPI(recorder, httptest.NewRequest(http.MethodGet, "/openapi.json", nil))
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("GET /openapi.json = %d", recorder.Code)
+	}
+	var spec struct {
+		OpenAPI string `json:"openapi"`
+		Paths   map[string]map[string]struct {
+			OperationID string `json:"operationId"`
+			RequestBody struct {
+				Content map[string]struct {
+					Schema map[string]interface{} `json:"schema"`
+				} `json:"content"`
+			} `json:"requestBody"`
+			Responses map[string]interface{} `json:"responses"`
+		} `json:"paths"`
+	}
+	if err := json.Unmarshal(recorder.Body.Bytes(), &spec); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if spec.OpenAPI != "3.0.3" {
+		t.Errorf("openapi = %q, want 3.0.3", spec.OpenAPI)
+	}
+	routes := s.routes()
+	if len(spec.Paths) != len(routes) {
+		t.Errorf("spec has %d paths, want %d", len(spec.Paths), len(routes))
+	}
+	operationIDs := map[string]bool{}
+	for _, rt := range routes {
+		operation, ok := spec.Paths[rt.Path]["post"]
+		if !ok {
+			t.Errorf("%s: no post operation", rt.Path)
+			continue
+		}
+		if operationIDs[operation.OperationID] {
+			t.Errorf("%s: duplicate operationId %s", rt.Path, operation.OperationID)
+		}
+		operationIDs[operation.OperationID] = true
+		for _, code := range append([]int{rt.Status, http.StatusUnauthorized, http.StatusForbidden}, rt.Errors...) {
+			if _, ok := operation.Responses[strconv.Itoa(code)]; !ok {
+				t.Errorf("%s: no %d response", rt.Path, code)
+			}
+		}
+		if rt.Request == nil {
+			continue
+		}
+		schema := operation.RequestBody.Content["application/json"].Schema
+		if schema == nil {
+			t.Errorf("%s: no request schema", rt.Path)
+			continue
+		}
+		checkDescribed(t, rt.Path, schema)
+	}
+}
+
+// checkDescribed reports properties of schema, at any depth, that have no
+// description.
+func checkDescribed(t *testing.T, path string, schema map[string]interface{}) {
+	t.Helper()
+	if items, ok := schema["items"].(map[string]interface{}); ok {
+		checkDescribed(t, path+"[]", items)
+	}
+	properties, _ := schema["properties"].(map[string]interface{})
+	for name, property := range properties {
+		property := property.(map[string]interface{})
+		if description, _ := property["description"].(string); description == "" {
+			t.Errorf("%s: property %s has no description", path, name)
+		}
+		checkDescribed(t, path+"."+name, property)
+	}
+}
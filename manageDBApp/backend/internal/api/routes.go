@@ -0,0 +1,177 @@
+package api
+
+import (
+	"mcp-db/pkg/types"
+	"net/http"
+)
+
+// route is an entry of the route table. The request and response types feed
+// the OpenAPI specification; Response is the type of the data field of a
+// successful types.Response. Operation names the route in authorization
+// policies and is the name of the MCP tool built from it, which Description
+// documents for MCP clients.
+type route struct {
+	Path        string
+	Operation   string
+	Summary     string
+	Description string
+	Handler     http.HandlerFunc
+	Request     interface{}
+	Response    interface{}
+	Status      int
+	Errors      []int
+}
+
+func (s *Server) routes() []route {
+	return []route{
+		{
+			Path:        "/databases/list",
+			Operation:   "list_databases",
+			Summary:     "List the database clusters in a namespace",
+			Description: "List the database clusters in a namespace, optionally only those of one type.",
+			Handler:     s.ListDatabases,
+			Request:     types.ListDatabasesRequest{},
+			Response:    []types.DBClusterInfo{},
+			Status:      http.StatusOK,
+			Errors:      []int{http.StatusBadRequest, http.StatusInternalServerError},
+		},
+		{
+			Path:        "/databases/create",
+			Operation:   "create_database",
+			Summary:     "Create a database cluster, or return it if it exists with the same spec",
+			Description: "Create a KubeBlocks database cluster. Retrying with the same spec returns the existing cluster.",
+			Handler:     s.CreateDatabase,
+			Request:     types.CreateDatabaseRequest{},
+			Response:    types.DBClusterInfo{},
+			Status:      http.StatusCreated,
+			Errors:      []int{http.StatusBadRequest, http.StatusConflict, http.StatusInternalServerError},
+		},
+		{
+			Path:        "/databases/delete",
+			Operation:   "delete_database",
+			Summary:     "Delete a database cluster and its RBAC objects",
+			Description: "Delete a database cluster. Clusters whose termination policy removes data require confirm to repeat the name.",
+			Handler:     s.DeleteDatabase,
+			Request:     types.DeleteDatabaseRequest{},
+			Status:      http.StatusOK,
+			Errors:      []int{http.StatusBadRequest, http.StatusForbidden, http.StatusNotFound, http.StatusInternalServerError},
+		},
+		{
+			Path:        "/databases/update",
+			Operation:   "update_database",
+			Summary:     "Change the termination policy of a database cluster",
+			Description: "Change the termination policy of a database cluster.",
+			Handler:     s.UpdateDatabase,
+			Request:     types.UpdateDatabaseRequest{},
+			Status:      http.StatusOK,
+			Errors:      []int{http.StatusBadRequest, http.StatusInternalServerError},
+		},
+		{
+			Path:        "/databases/garbage",
+			Operation:   "garbage_report",
+			Summary:     "List RBAC objects left behind by deleted clusters",
+			Description: "List ServiceAccounts, Roles and RoleBindings left behind by deleted database clusters.",
+			Handler:     s.GarbageReport,
+			Request:     types.ListDatabasesRequest{},
+			Response:    types.GarbageReport{},
+			Status:      http.StatusOK,
+			Errors:      []int{http.StatusBadRequest, http.StatusInternalServerError},
+		},
+		{
+			Path:        "/engines",
+			Operation:   "list_engines",
+			Summary:     "List the engines installed in the target cluster",
+			Description: "List the database engines installed in the target cluster and the versions that can be created.",
+			Handler:     s.ListEngines,
+			Request:     types.ListEnginesRequest{},
+			Response:    []types.EngineInfo{},
+			Status:      http.StatusOK,
+			Errors:      []int{http.StatusBadRequest, http.StatusInternalServerError},
+		},
+		{
+			Path:        "/engines/reload",
+			Operation:   "reload_engines",
+			Summary:     "Reload the engine registry file",
+			Description: "Reload the engine registry file.",
+			Handler:     s.ReloadEngines,
+			Status:      http.StatusOK,
+			Errors:      []int{http.StatusBadRequest},
+		},
+		{
+			Path:        "/databases/connect",
+			Operation:   "get_database_connection",
+			Summary:     "Get the connection info of a database cluster",
+			Description: "Get the address, credentials, URI, JDBC URL and CLI command of a database cluster.",
+			Handler:     s.GetDatabaseConn,
+			Request:     types.GetDatabasesRequest{},
+			Response:    types.DatabasesResponse{},
+			Status:      http.StatusOK,
+			Errors:      []int{http.StatusBadRequest, http.StatusInternalServerError},
+		},
+		{
+			Path:        "/databases/get",
+			Operation:   "get_database",
+			Summary:     "Get a database cluster with its pods and volumes",
+			Description: "Get one database cluster with the roles of its pods and its volumes.",
+			Handler:     s.GetDatabase,
+			Request:     types.GetDatabasesRequest{},
+			Response:    types.DBClusterInfo{},
+			Status:      http.StatusOK,
+			Errors:      []int{http.StatusBadRequest, http.StatusInternalServerError},
+		},
+		{
+			Path:        "/databases/expose",
+			Operation:   "expose_database",
+			Summary:     "Enable or disable external access to a database cluster",
+			Description: "Enable or disable external access to a database cluster through a LoadBalancer or NodePort service.",
+			Handler:     s.ExposeDatabase,
+			Request:     types.ExposeDatabaseRequest{},
+			Response:    types.ExternalAccess{},
+			Status:      http.StatusOK,
+			Errors:      []int{http.StatusBadRequest, http.StatusInternalServerError},
+		},
+		{
+			Path:        "/databases/rotate-credentials",
+			Operation:   "rotate_credentials",
+			Summary:     "Set a new generated password for the admin user",
+			Description: "Set a new generated password for the admin user of a database cluster.",
+			Handler:     s.RotateCredentials,
+			Request:     types.RotateCredentialsRequest{},
+			Response:    types.RotateCredentialsResponse{},
+			Status:      http.StatusOK,
+			Errors:      []int{http.StatusBadRequest, http.StatusInternalServerError},
+		},
+		{
+			Path:        "/kubeconfigs/register",
+			Operation:   "register_kubeconfig",
+			Summary:     "Register a kubeconfig and get a handle for kubeconfig_ref",
+			Description: "Register a kubeconfig and get a handle to pass as kubeconfig_ref instead of the kubeconfig.",
+			Handler:     s.RegisterKubeconfig,
+			Request:     types.RegisterKubeconfigRequest{},
+			Response:    types.KubeconfigHandle{},
+			Status:      http.StatusCreated,
+			Errors:      []int{http.StatusBadRequest, http.StatusInternalServerError},
+		},
+		{
+			Path:        "/kubeconfigs/revoke",
+			Operation:   "revoke_kubeconfig",
+			Summary:     "Revoke a kubeconfig handle",
+			Description: "Revoke a kubeconfig handle.",
+			Handler:     s.RevokeKubeconfig,
+			Request:     types.RevokeKubeconfigRequest{},
+			Status:      http.StatusOK,
+			Errors:      []int{http.StatusBadRequest, http.StatusInternalServerError},
+		},
+		{
+			Path:        "/audit/events",
+			Operation:   "list_audit_events",
+			Summary:     "List the recent audit events, newest first",
+			Description: "List the recent audit events of operations that changed clusters or read credentials, newest first.",
+			Handler:     s.ListAuditEvents,
+			Request:     types.ListAuditEventsRequest{},
+			Response:    []types.AuditEvent{},
+			Status:      http.StatusOK,
+			Errors:      []int{http.StatusBadRequest},
+		},
+	}
+}
@@ -1,34 +1,172 @@
 package api
 
 import (
+	"context"
+	"errors"
 	"github.com/gorilla/mux"
+	"k8s.io/client-go/rest"
+	"mcp-db/internal/audit"
+	"mcp-db/internal/auth"
 	"mcp-db/internal/k8s"
+	"mcp-db/internal/logging"
+	"mcp-db/internal/metrics"
+	"net"
 	"net/http"
+	"sync/atomic"
+	"time"
 )
 
+// Defaults of the HTTP server timeouts.
+const (
+	DefaultReadTimeout  = 30 * time.Second
+	DefaultWriteTimeout = 2 * time.Minute
+	DefaultIdleTimeout  = 2 * time.Minute
+)
+
+// DefaultDrainDelay is how long Shutdown should keep serving after reporting the
+// server as not ready: two periods of the readiness probe of the
+// deployment, for the endpoint to be removed before connections are
+// refused.
+const DefaultDrainDelay = 10 * time.Second
+
 type Server struct {
-	router    *mux.Router
-	k8sClient *k8s.Client
-	addr      string
+	router      *mux.Router
+	httpServer  *http.Server
+	clients     *k8s.ClientCache
+	kubeconfigs k8s.KubeconfigStore
+	inCluster   *rest.Config
+	policy      *auth.Policy
+	auditLog    *audit.Logger
+	readiness   map[string]ReadinessCheck
+	draining    atomic.Bool
+	drainDelay  time.Duration
+	cancel      context.CancelFunc
+}
+
+// Options configure a Server.
+type Options struct {
+	// Kubeconfigs stores the kubeconfigs referenced by kubeconfig_ref.
+	Kubeconfigs k8s.KubeconfigStore
+	// InClusterConfig enables in-cluster mode: requests without a
+	// kubeconfig act as their authenticated caller by impersonation
+	// through this config.
+	InClusterConfig *rest.Config
+	// ClientTTL is how long an unused Kubernetes client stays cached,
+	// k8s.DefaultClientTTL when zero.
+	ClientTTL time.Duration
+	// MaxClients bounds the number of cached Kubernetes clients,
+	// k8s.DefaultMaxClients when zero.
+	MaxClients int
+	// Authenticator identifies callers. Requests it authenticates carry
+	// their identity in the context.
+	Authenticator auth.Authenticator
+	// RequireAuth rejects requests the Authenticator does not identify,
+	// except for the OpenAPI specification and the metrics.
+	RequireAuth bool
+	// Policy restricts the operations and namespaces of authenticated
+	// callers. Without one every caller may perform every operation.
+	Policy *auth.Policy
+	// AuditLog records the operations that change clusters or read their
+	// credentials. Without one nothing is audited.
+	AuditLog *audit.Logger
+	// ReadTimeout, WriteTimeout and IdleTimeout bound the reading of a
+	// request, the writing of its response and idle keep-alive
+	// connections. Zero values take the defaults.
+	ReadTimeout  time.Duration
+	WriteTimeout time.Duration
+	IdleTimeout  time.Duration
+	// DrainDelay is how long Shutdown keeps serving after reporting the
+	// server as not ready. Zero stops at once.
+	DrainDelay time.Duration
+	// ReadinessChecks are the dependencies /readyz checks by name, on top
+	// of the engine registry.
+	ReadinessChecks map[string]ReadinessCheck
 }
 
-func NewServer(addr string) *Server {
+func NewServer(addr string, opts Options) *Server {
 	server := &Server{
-		router: mux.NewRouter(),
-		addr:   addr,
+		router:      mux.NewRouter(),
+		clients:     k8s.NewClientCache(opts.ClientTTL, opts.MaxClients),
+		kubeconfigs: opts.Kubeconfigs,
+		inCluster:   opts.InClusterConfig,
+		policy:      opts.Policy,
+		auditLog:    opts.AuditLog,
+		readiness:   map[string]ReadinessCheck{"engines": checkEngines},
+	}
+	for name, check := range opts.ReadinessChecks {
+		server.readiness[name] = check
+	}
+	server.router.Use(metrics.Middleware)
+	if opts.Authenticator != nil {
+		server.router.Use(auth.Middleware(opts.Authenticator, opts.RequireAuth, "/openapi.json", "/metrics", "/healthz", "/readyz"))
 	}
 	server.setupRoutes()
+	server.httpServer = &http.Server{
+		Addr:              addr,
+		Handler:           logging.Middleware(server.router),
+		ReadHeaderTimeout: withDefault(opts.ReadTimeout, DefaultReadTimeout),
+		ReadTimeout:       withDefault(opts.ReadTimeout, DefaultReadTimeout),
+		WriteTimeout:      withDefault(opts.WriteTimeout, DefaultWriteTimeout),
+		IdleTimeout:       withDefault(opts.IdleTimeout, DefaultIdleTimeout),
+	}
+	server.drainDelay = opts.DrainDelay
+	// Requests are canceled when the client goes away, when their response
+	// can no longer be written and when Shutdown gives up on them.
+	base, cancel := context.WithCancel(context.Background())
+	server.cancel = cancel
+	server.httpServer.BaseContext = func(net.Listener) context.Context { return base }
+	server.httpServer.Handler = withDeadline(server.httpServer.Handler, server.httpServer.WriteTimeout)
 	return server
 }
 
+// withDeadline cancels the context of each request after timeout.
+func withDeadline(next http.Handler, timeout time.Duration) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx, cancel := context.WithTimeout(r.Context(), timeout)
+		defer cancel()
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+func withDefault(d, def time.Duration) time.Duration {
+	if d <= 0 {
+		return def
+	}
+	return d
+}
+
 func (s *Server) setupRoutes() {
-	api := s.router.PathPrefix("/databases").Subrouter()
-	api.HandleFunc("/list", s.ListDatabases).Methods(http.MethodPost)
-	api.HandleFunc("/create", s.CreateDatabase).Methods(http.MethodPost)
-	api.HandleFunc("/delete", s.DeleteDatabase).Methods(http.MethodPost)
-	api.HandleFunc("/connect", s.GetDatabaseConn).Methods(http.MethodPost)
+	for _, rt := range s.routes() {
+		s.router.HandleFunc(rt.Path, s.audit(rt.Operation, s.authorize(rt.Operation, rt.Handler))).Methods(http.MethodPost)
+	}
+	s.router.Handle("/mcp", s.MCP())
+	s.router.HandleFunc("/openapi.json", s.OpenAPI).Methods(http.MethodGet)
+	s.router.Handle("/metrics", metrics.Handler()).Methods(http.MethodGet)
+	s.router.HandleFunc("/healthz", s.Healthz).Methods(http.MethodGet)
+	s.router.HandleFunc("/readyz", s.Readyz).Methods(http.MethodGet)
 }
 
+// Start serves until Shutdown is called, when it returns nil.
 func (s *Server) Start() error {
-	return http.ListenAndServe(s.addr, s.router)
+	if err := s.httpServer.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+// Shutdown reports the server as not ready and keeps serving for the drain
+// delay, so that the readiness probe fails and the endpoint is removed
+// first. It then stops accepting connections and waits for the requests in
+// flight to complete until ctx is done, when it cancels them.
+func (s *Server) Shutdown(ctx context.Context) error {
+	s.draining.Store(true)
+	timer := time.NewTimer(s.drainDelay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+	case <-ctx.Done():
+	}
+	err := s.httpServer.Shutdown(ctx)
+	s.cancel()
+	return err
 }
@@ -0,0 +1,120 @@
+// Package audit records who performed which operation on what, with the
+// outcome, and keeps the recent events for querying.
+package audit
+
+import (
+	"log/slog"
+	"mcp-db/pkg/types"
+	"sync"
+	"time"
+)
+
+// Outcomes of an audited operation.
+const (
+	OutcomeSuccess = "success"
+	OutcomeFailure = "failure"
+	OutcomeDenied  = "denied"
+)
+
+// DefaultRecent is the number of events kept in memory for queries.
+const DefaultRecent = 1000
+
+// Sink receives every audit event.
+type Sink interface {
+	Write(event types.AuditEvent) error
+	Close() error
+}
+
+// Logger writes audit events to its sinks and keeps the most recent ones.
+type Logger struct {
+	mu     sync.Mutex
+	sinks  []Sink
+	recent []types.AuditEvent
+	next   int
+	full   bool
+}
+
+// NewLogger returns a logger keeping the last recent events, DefaultRecent
+// when zero.
+func NewLogger(recent int, sinks ...Sink) *Logger {
+	if recent <= 0 {
+		recent = DefaultRecent
+	}
+	return &Logger{sinks: sinks, recent: make([]types.AuditEvent, recent)}
+}
+
+// Record stores event and writes it to every sink. A failing sink does not
+// fail the operation and is only logged.
+func (l *Logger) Record(event types.AuditEvent) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.recent[l.next] = event
+	l.next = (l.next + 1) % len(l.recent)
+	l.full = l.full || l.next == 0
+	for _, sink := range l.sinks {
+		if err := sink.Write(event); err != nil {
+			slog.Error("Failed to write audit event", "operation", event.Operation, "error", err)
+		}
+	}
+}
+
+// Filter selects recent events. Empty fields match any event.
+type Filter struct {
+	Operation string
+	Identity  string
+	Target    string
+	Outcome   string
+	Since     time.Time
+	Limit     int
+}
+
+func (f Filter) matches(event types.AuditEvent) bool {
+	if (f.Operation != "" && event.Operation != f.Operation) ||
+		(f.Identity != "" && event.Identity != f.Identity) ||
+		(f.Target != "" && event.Target != f.Target) ||
+		(f.Outcome != "" && event.Outcome != f.Outcome) {
+		return false
+	}
+	if !f.Since.IsZero() {
+		at, err := time.Parse(time.RFC3339Nano, event.Time)
+		if err != nil || at.Before(f.Since) {
+			return false
+		}
+	}
+	return true
+}
+
+// Recent returns the recent events matching filter, newest first.
+func (l *Logger) Recent(filter Filter) []types.AuditEvent {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	count := l.next
+	if l.full {
+		count = len(l.recent)
+	}
+	events := []types.AuditEvent{}
+	for i := 1; i <= count; i++ {
+		event := l.recent[(l.next-i+len(l.recent))%len(l.recent)]
+		if !filter.matches(event) {
+			continue
+		}
+		events = append(events, event)
+		if filter.Limit > 0 && len(events) == filter.Limit {
+			break
+		}
+	}
+	return events
+}
+
+// Close closes every sink.
+func (l *Logger) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	var first error
+	for _, sink := range l.sinks {
+		if err := sink.Close(); err != nil && first == nil {
+			first = err
+		}
+	}
+	return first
+}
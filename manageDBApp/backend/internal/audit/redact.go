@@ -0,0 +1,36 @@
+package audit
+
+// Redacted replaces secret values in audited parameters.
+const Redacted = "[REDACTED]"
+
+// secretFields are the request fields never written to the audit log.
+var secretFields = map[string]bool{
+	"kubeconfig":     true,
+	"kubeconfig_ref": true,
+	"handle":         true,
+	"password":       true,
+	"token":          true,
+}
+
+// Redact replaces the values of secret fields, at any depth, in params.
+func Redact(params map[string]interface{}) map[string]interface{} {
+	for key, value := range params {
+		if secretFields[key] {
+			params[key] = Redacted
+			continue
+		}
+		redactValue(value)
+	}
+	return params
+}
+
+func redactValue(value interface{}) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		Redact(v)
+	case []interface{}:
+		for _, item := range v {
+			redactValue(item)
+		}
+	}
+}
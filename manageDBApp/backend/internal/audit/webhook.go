@@ -0,0 +1,77 @@
+package audit
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"mcp-db/pkg/types"
+	"net/http"
+	"time"
+)
+
+// webhookQueue is the number of events waiting for delivery before new
+// events are dropped.
+const webhookQueue = 1000
+
+// WebhookSink posts every event as JSON to a URL. Delivery is asynchronous
+// so a slow receiver does not delay operations; events that do not fit in
+// the queue are dropped and reported as errors.
+type WebhookSink struct {
+	url    string
+	client *http.Client
+	queue  chan types.AuditEvent
+	done   chan struct{}
+}
+
+func NewWebhookSink(url string) *WebhookSink {
+	sink := &WebhookSink{
+		url:    url,
+		client: &http.Client{Timeout: 5 * time.Second},
+		queue:  make(chan types.AuditEvent, webhookQueue),
+		done:   make(chan struct{}),
+	}
+	go sink.deliver()
+	return sink
+}
+
+func (s *WebhookSink) Write(event types.AuditEvent) error {
+	select {
+	case s.queue <- event:
+		return nil
+	default:
+		return fmt.Errorf("audit webhook queue is full, dropped %s event", event.Operation)
+	}
+}
+
+func (s *WebhookSink) deliver() {
+	defer close(s.done)
+	for event := range s.queue {
+		if err := s.post(event); err != nil {
+			slog.Error("Failed to deliver audit event to webhook", "operation", event.Operation, "error", err)
+		}
+	}
+}
+
+func (s *WebhookSink) post(event types.AuditEvent) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	resp, err := s.client.Post(s.url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= http.StatusMultipleChoices {
+		return fmt.Errorf("webhook answered %s", resp.Status)
+	}
+	return nil
+}
+
+// Close delivers the queued events and stops the sink.
+func (s *WebhookSink) Close() error {
+	close(s.queue)
+	<-s.done
+	return nil
+}
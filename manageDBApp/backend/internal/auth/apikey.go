@@ -0,0 +1,103 @@
+package auth
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"os"
+	"sigs.k8s.io/yaml"
+	"strings"
+)
+
+// APIKeyHeader carries a static API key. Keys are also accepted as bearer
+// tokens.
+const APIKeyHeader = "X-API-Key"
+
+// APIKeyFile is the schema of the API key file. Each key is given either in
+// clear or as the hex SHA-256 of the key, so the file need not hold secrets.
+type APIKeyFile struct {
+	Keys []APIKey `json:"keys"`
+}
+
+type APIKey struct {
+	Name   string   `json:"name"`
+	Key    string   `json:"key,omitempty"`
+	SHA256 string   `json:"sha256,omitempty"`
+	Groups []string `json:"groups,omitempty"`
+}
+
+// APIKeyAuthenticator authenticates static API keys, the identity being the
+// name of the key.
+type APIKeyAuthenticator struct {
+	keys []apiKeyEntry
+}
+
+type apiKeyEntry struct {
+	hash     []byte
+	identity Identity
+}
+
+// LoadAPIKeys reads an API key file in YAML or JSON.
+func LoadAPIKeys(path string) (*APIKeyAuthenticator, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read API keys: %w", err)
+	}
+	var file APIKeyFile
+	if err := yaml.UnmarshalStrict(data, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse API keys %s: %w", path, err)
+	}
+	return NewAPIKeyAuthenticator(file.Keys)
+}
+
+func NewAPIKeyAuthenticator(keys []APIKey) (*APIKeyAuthenticator, error) {
+	a := &APIKeyAuthenticator{}
+	for _, key := range keys {
+		if key.Name == "" {
+			return nil, fmt.Errorf("API key without a name")
+		}
+		var hash []byte
+		switch {
+		case key.Key != "" && key.SHA256 != "":
+			return nil, fmt.Errorf("API key %s sets both key and sha256", key.Name)
+		case key.Key != "":
+			sum := sha256.Sum256([]byte(key.Key))
+			hash = sum[:]
+		case key.SHA256 != "":
+			decoded, err := hex.DecodeString(key.SHA256)
+			if err != nil || len(decoded) != sha256.Size {
+				return nil, fmt.Errorf("API key %s has an invalid sha256", key.Name)
+			}
+			hash = decoded
+		default:
+			return nil, fmt.Errorf("API key %s sets neither key nor sha256", key.Name)
+		}
+		a.keys = append(a.keys, apiKeyEntry{hash: hash, identity: Identity{Name: key.Name, Groups: key.Groups, Source: SourceAPIKey}})
+	}
+	return a, nil
+}
+
+func (a *APIKeyAuthenticator) Authenticate(r *http.Request) (*Identity, error) {
+	key := strings.TrimSpace(r.Header.Get(APIKeyHeader))
+	fromHeader := key != ""
+	if !fromHeader {
+		key, _ = BearerToken(r)
+	}
+	if key == "" {
+		return nil, ErrUnauthenticated
+	}
+	sum := sha256.Sum256([]byte(key))
+	for _, entry := range a.keys {
+		if subtle.ConstantTimeCompare(sum[:], entry.hash) == 1 {
+			identity := entry.identity
+			return &identity, nil
+		}
+	}
+	// A bearer token may be meant for another authenticator.
+	if !fromHeader {
+		return nil, ErrUnauthenticated
+	}
+	return nil, fmt.Errorf("unknown API key")
+}
@@ -0,0 +1,128 @@
+// Package auth authenticates HTTP callers and carries their identity in the
+// request context.
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"mcp-db/pkg/types"
+	"net/http"
+	"slices"
+	"strings"
+)
+
+// ErrUnauthenticated is returned when a request carries no credentials an
+// authenticator accepts.
+var ErrUnauthenticated = errors.New("authentication required")
+
+// Sources of identities.
+const (
+	SourceAPIKey      = "apikey"
+	SourceJWT         = "jwt"
+	SourceTokenReview = "tokenreview"
+)
+
+// Identity is an authenticated caller.
+type Identity struct {
+	Name   string
+	UID    string
+	Groups []string
+	Extra  map[string][]string
+	// Source is the authenticator that established the identity. Only
+	// TokenReview identities are users of the Kubernetes cluster; the names
+	// and groups of the others come from the service configuration or from
+	// the caller's token.
+	Source string
+}
+
+// Authenticator derives an identity from a request. It returns
+// ErrUnauthenticated when the request has no credentials it understands
+// and another error when the credentials are invalid.
+type Authenticator interface {
+	Authenticate(r *http.Request) (*Identity, error)
+}
+
+type identityKey struct{}
+
+// WithIdentity returns ctx carrying identity.
+func WithIdentity(ctx context.Context, identity *Identity) context.Context {
+	return context.WithValue(ctx, identityKey{}, identity)
+}
+
+// IdentityFrom returns the identity carried by ctx.
+func IdentityFrom(ctx context.Context) (*Identity, bool) {
+	identity, ok := ctx.Value(identityKey{}).(*Identity)
+	return identity, ok && identity != nil
+}
+
+// BearerToken returns the token of an "Authorization: Bearer" header.
+func BearerToken(r *http.Request) (string, bool) {
+	scheme, token, ok := strings.Cut(r.Header.Get("Authorization"), " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") || strings.TrimSpace(token) == "" {
+		return "", false
+	}
+	return strings.TrimSpace(token), true
+}
+
+// Chain tries authenticators in order and returns the first identity. It
+// returns ErrUnauthenticated when none understands the credentials, and
+// otherwise the first error of an authenticator that rejected them.
+func Chain(authenticators ...Authenticator) Authenticator {
+	return chain(authenticators)
+}
+
+type chain []Authenticator
+
+func (c chain) Authenticate(r *http.Request) (*Identity, error) {
+	var rejected error
+	for _, authenticator := range c {
+		identity, err := authenticator.Authenticate(r)
+		if err == nil {
+			return identity, nil
+		}
+		if !errors.Is(err, ErrUnauthenticated) && rejected == nil {
+			rejected = err
+		}
+	}
+	if rejected != nil {
+		return nil, rejected
+	}
+	return nil, ErrUnauthenticated
+}
+
+// Middleware authenticates requests that carry credentials and stores the
+// identity in their context. Requests with invalid credentials are rejected
+// with 401. Requests without credentials are rejected too when required is
+// set, except on the public paths; otherwise they pass through
+// unauthenticated and handlers that need an identity reject them.
+func Middleware(authenticator Authenticator, required bool, public ...string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			identity, err := authenticator.Authenticate(r)
+			if errors.Is(err, ErrUnauthenticated) {
+				if required && !slices.Contains(public, r.URL.Path) {
+					Unauthorized(w, "Authentication required")
+					return
+				}
+				next.ServeHTTP(w, r)
+				return
+			}
+			if err != nil {
+				slog.WarnContext(r.Context(), "Authentication failed", "error", err)
+				Unauthorized(w, "Invalid credentials")
+				return
+			}
+			next.ServeHTTP(w, r.WithContext(WithIdentity(r.Context(), identity)))
+		})
+	}
+}
+
+// Unauthorized writes a 401 response in the format of the API.
+func Unauthorized(w http.ResponseWriter, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("WWW-Authenticate", "Bearer")
+	w.WriteHeader(http.StatusUnauthorized)
+	json.NewEncoder(w).Encode(types.Response{Success: false, Message: message})
+}
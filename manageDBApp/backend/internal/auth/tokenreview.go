@@ -0,0 +1,62 @@
+package auth
+
+import (
+	"fmt"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"net/http"
+	"slices"
+	"strings"
+)
+
+// TokenReviewAuthenticator validates bearer tokens with the TokenReview API
+// of a Kubernetes cluster, so callers authenticate with their own
+// Kubernetes credentials.
+type TokenReviewAuthenticator struct {
+	clientSet kubernetes.Interface
+	audiences []string
+}
+
+// DefaultTokenAudience is the audience Kubernetes tokens must be minted for,
+// as with "kubectl create token --audience mcp-db", so tokens meant for
+// other services are not accepted.
+const DefaultTokenAudience = "mcp-db"
+
+// NewTokenReviewAuthenticator accepts tokens issued for any of audiences, or
+// for the API server itself when audiences is empty.
+func NewTokenReviewAuthenticator(clientSet kubernetes.Interface, audiences []string) *TokenReviewAuthenticator {
+	return &TokenReviewAuthenticator{clientSet: clientSet, audiences: audiences}
+}
+
+func (a *TokenReviewAuthenticator) Authenticate(r *http.Request) (*Identity, error) {
+	token, ok := BearerToken(r)
+	if !ok {
+		return nil, ErrUnauthenticated
+	}
+	review, err := a.clientSet.AuthenticationV1().TokenReviews().Create(r.Context(), &authenticationv1.TokenReview{
+		Spec: authenticationv1.TokenReviewSpec{Token: token, Audiences: a.audiences},
+	}, metav1.CreateOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("token review failed: %w", err)
+	}
+	if !review.Status.Authenticated {
+		return nil, fmt.Errorf("token rejected: %s", review.Status.Error)
+	}
+	if len(a.audiences) > 0 && !slices.ContainsFunc(review.Status.Audiences, func(audience string) bool {
+		return slices.Contains(a.audiences, audience)
+	}) {
+		return nil, fmt.Errorf("token is not issued for audience %s", strings.Join(a.audiences, ", "))
+	}
+	extra := make(map[string][]string, len(review.Status.User.Extra))
+	for key, values := range review.Status.User.Extra {
+		extra[key] = values
+	}
+	return &Identity{
+		Name:   review.Status.User.Username,
+		UID:    review.Status.User.UID,
+		Groups: review.Status.User.Groups,
+		Extra:  extra,
+		Source: SourceTokenReview,
+	}, nil
+}
@@ -0,0 +1,80 @@
+package k8s
+
+import (
+	"fmt"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+const (
+	KubeBlocksGroup = "apps.kubeblocks.io"
+
+	// APIVersionV1Alpha1 Clusters reference a ClusterDefinition and a
+	// ClusterVersion (clusterDefinitionRef/clusterVersionRef).
+	APIVersionV1Alpha1 = "v1alpha1"
+	// APIVersionV1 Clusters reference a ComponentDefinition and a
+	// serviceVersion per component (componentDef/serviceVersion).
+	APIVersionV1 = "v1"
+)
+
+// kubeBlocksGVR returns the resource of the KubeBlocks API group in version.
+func kubeBlocksGVR(version, resource string) schema.GroupVersionResource {
+	return schema.GroupVersionResource{Group: KubeBlocksGroup, Version: version, Resource: resource}
+}
+
+// KubeBlocksAPIVersion reports which KubeBlocks API the target cluster
+// serves for Clusters, preferring v1 when both are available. The first
+// successful discovery is kept for the life of the Client; a failed one is
+// retried on the next call, so a transient error does not break a cached
+// Client.
+func (c *Client) KubeBlocksAPIVersion() (string, error) {
+	c.apiVersionMu.Lock()
+	defer c.apiVersionMu.Unlock()
+	if c.apiVersion != "" {
+		return c.apiVersion, nil
+	}
+	version, err := c.discoverKubeBlocksAPIVersion()
+	if err != nil {
+		return "", err
+	}
+	c.apiVersion = version
+	return version, nil
+}
+
+func (c *Client) discoverKubeBlocksAPIVersion() (string, error) {
+	for _, version := range []string{APIVersionV1, APIVersionV1Alpha1} {
+		resources, err := c.ClientSet.Discovery().ServerResourcesForGroupVersion(KubeBlocksGroup + "/" + version)
+		if apierrors.IsNotFound(err) {
+			continue
+		}
+		if err != nil {
+			return "", fmt.Errorf("failed to discover %s/%s: %w", KubeBlocksGroup, version, err)
+		}
+		for _, resource := range resources.APIResources {
+			if resource.Name == "clusters" {
+				return version, nil
+			}
+		}
+	}
+	return "", fmt.Errorf("KubeBlocks is not installed: %s serves no clusters resource", KubeBlocksGroup)
+}
+
+// opsRequestGVR returns the OpsRequest resource that goes with Clusters of
+// apiVersion. KubeBlocks 1.0, which serves v1 Clusters, moved OpsRequests
+// to their own group.
+func opsRequestGVR(apiVersion string) schema.GroupVersionResource {
+	if apiVersion == APIVersionV1Alpha1 {
+		return kubeBlocksGVR(APIVersionV1Alpha1, "opsrequests")
+	}
+	return schema.GroupVersionResource{Group: "operations.kubeblocks.io", Version: "v1alpha1", Resource: "opsrequests"}
+}
+
+// clusterGVR returns the Cluster resource in the API version served by the
+// target cluster.
+func (c *Client) clusterGVR() (schema.GroupVersionResource, error) {
+	version, err := c.KubeBlocksAPIVersion()
+	if err != nil {
+		return schema.GroupVersionResource{}, err
+	}
+	return kubeBlocksGVR(version, "clusters"), nil
+}
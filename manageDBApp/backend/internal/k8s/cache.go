@@ -0,0 +1,114 @@
+package k8s
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"encoding/hex"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultClientTTL is how long an unused client stays cached.
+	DefaultClientTTL = 10 * time.Minute
+	// DefaultMaxClients bounds the number of cached clients.
+	DefaultMaxClients = 256
+)
+
+// ClientCache shares clients between requests of the same tenant, keyed by
+// a fingerprint of their credentials. Entries expire after ttl without use,
+// and beyond max entries the least recently used one is evicted. It is safe
+// for concurrent use.
+type ClientCache struct {
+	ttl     time.Duration
+	max     int
+	mu      sync.Mutex
+	entries map[string]*list.Element
+	// order holds the entries from the most to the least recently used.
+	order *list.List
+}
+
+type cachedClient struct {
+	key       string
+	client    *Client
+	expiresAt time.Time
+}
+
+// NewClientCache returns a cache of at most maxClients clients unused for
+// at most ttl. Zero values take the defaults.
+func NewClientCache(ttl time.Duration, maxClients int) *ClientCache {
+	if ttl <= 0 {
+		ttl = DefaultClientTTL
+	}
+	if maxClients <= 0 {
+		maxClients = DefaultMaxClients
+	}
+	return &ClientCache{ttl: ttl, max: maxClients, entries: map[string]*list.Element{}, order: list.New()}
+}
+
+// Fingerprint returns the cache key of credentials, without keeping them.
+func Fingerprint(parts ...string) string {
+	hash := sha256.New()
+	for _, part := range parts {
+		hash.Write([]byte(part))
+		hash.Write([]byte{0})
+	}
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// Get returns the client cached under key, building and caching it with
+// build when there is none. Clients are built outside the lock; when two
+// requests build the same client, the first one cached wins.
+func (c *ClientCache) Get(key string, build func() (*Client, error)) (*Client, error) {
+	c.mu.Lock()
+	if client, ok := c.lookup(key, time.Now()); ok {
+		c.mu.Unlock()
+		return client, nil
+	}
+	c.mu.Unlock()
+
+	client, err := build()
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := time.Now()
+	if cached, ok := c.lookup(key, now); ok {
+		return cached, nil
+	}
+	c.entries[key] = c.order.PushFront(&cachedClient{key: key, client: client, expiresAt: now.Add(c.ttl)})
+	for c.order.Len() > c.max {
+		c.remove(c.order.Back())
+	}
+	return client, nil
+}
+
+// lookup returns the live client cached under key and marks it used. It
+// purges expired entries first, which are all at the back of order.
+func (c *ClientCache) lookup(key string, now time.Time) (*Client, bool) {
+	for back := c.order.Back(); back != nil && now.After(back.Value.(*cachedClient).expiresAt); back = c.order.Back() {
+		c.remove(back)
+	}
+	element, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	entry := element.Value.(*cachedClient)
+	entry.expiresAt = now.Add(c.ttl)
+	c.order.MoveToFront(element)
+	return entry.client, true
+}
+
+func (c *ClientCache) remove(element *list.Element) {
+	c.order.Remove(element)
+	delete(c.entries, element.Value.(*cachedClient).key)
+}
+
+// Len returns the number of cached clients.
+func (c *ClientCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.order.Len()
+}
@@ -0,0 +1,155 @@
+package k8s
+
+import (
+	"fmt"
+	"k8s.io/client-go/rest"
+	"sync"
+	"testing"
+	"time"
+)
+
+func testKubeconfig(server, token string) string {
+	return fmt.Sprintf(`apiVersion: v1
+kind: Config
+clusters:
+- name: c
+  cluster:
+    server: %s
+contexts:
+- name: c
+  context:
+    cluster: c
+    user: u
+current-context: c
+users:
+- name: u
+  user:
+    token: %s
+`, server, token)
+}
+
+func TestClientCacheTenantsGetTheirOwnClient(t *testing.T) {
+	const tenants, requests = 8, 20
+	cache := NewClientCache(time.Minute, 0)
+	base := &rest.Config{Host: "https://in-cluster.example"}
+	type tenant struct {
+		key   string
+		build func() (*Client, error)
+		check func(*Client) error
+	}
+	var all []tenant
+	for i := 0; i < tenants; i++ {
+		server, token := fmt.Sprintf("https://cluster-%d.example", i), fmt.Sprintf("token-%d", i)
+		kubeconfig := testKubeconfig(server, token)
+		all = append(all, tenant{
+			key:   Fingerprint("kubeconfig", kubeconfig),
+			build: func() (*Client, error) { return NewClient(kubeconfig) },
+			check: func(c *Client) error {
+				if c.Config().Host != server || c.Config().BearerToken != token {
+					return fmt.Errorf("got the client of %s, want %s", c.Config().Host, server)
+				}
+				return nil
+			},
+		})
+		user := fmt.Sprintf("user-%d", i)
+		all = append(all, tenant{
+			key: Fingerprint("impersonate", user),
+			build: func() (*Client, error) {
+				return NewImpersonatingClient(base, rest.ImpersonationConfig{UserName: user})
+			},
+			check: func(c *Client) error {
+				if c.Config().Impersonate.UserName != user {
+					return fmt.Errorf("got the client of %q, want %q", c.Config().Impersonate.UserName, user)
+				}
+				return nil
+			},
+		})
+	}
+
+	got := make([][]*Client, len(all))
+	for i := range got {
+		got[i] = make([]*Client, requests)
+	}
+	var wg sync.WaitGroup
+	for i, tenant := range all {
+		for j := 0; j < requests; j++ {
+			wg.Add(1)
+			go func(i, j int) {
+				defer wg.Done()
+				client, err := cache.Get(tenant.key, tenant.build)
+				if err != nil {
+					t.Error(err)
+					return
+				}
+				if err := tenant.check(client); err != nil {
+					t.Error(err)
+				}
+				got[i][j] = client
+			}(i, j)
+		}
+	}
+	wg.Wait()
+
+	owners := map[*Client]int{}
+	for i, clients := range got {
+		for _, client := range clients {
+			if client != clients[0] {
+				t.Errorf("tenant %d got different clients across requests", i)
+			}
+			if owner, ok := owners[client]; ok && owner != i {
+				t.Errorf("tenants %d and %d share a client", owner, i)
+			}
+			owners[client] = i
+		}
+	}
+	if cache.Len() != len(all) {
+		t.Errorf("Len() = %d, want %d", cache.Len(), len(all))
+	}
+}
+
+func TestClientCacheEvictsLeastRecentlyUsed(t *testing.T) {
+	cache := NewClientCache(time.Minute, 2)
+	builds := map[string]int{}
+	get := func(key string) *Client {
+		client, err := cache.Get(key, func() (*Client, error) {
+			builds[key]++
+			return &Client{}, nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return client
+	}
+	a := get("a")
+	get("b")
+	if get("a") != a {
+		t.Fatal("a was rebuilt while cached")
+	}
+	get("c") // evicts b, the least recently used
+	if cache.Len() != 2 {
+		t.Errorf("Len() = %d, want 2", cache.Len())
+	}
+	get("a")
+	get("b")
+	if builds["a"] != 1 || builds["b"] != 2 {
+		t.Errorf("builds = %v, want a once and b twice", builds)
+	}
+}
+
+func TestClientCacheExpires(t *testing.T) {
+	cache := NewClientCache(10*time.Millisecond, 0)
+	builds := 0
+	build := func() (*Client, error) {
+		builds++
+		return &Client{}, nil
+	}
+	first, _ := cache.Get("a", build)
+	time.Sleep(20 * time.Millisecond)
+	second, _ := cache.Get("a", build)
+	if first == second || builds != 2 {
+		t.Errorf("expired client was reused, %d builds", builds)
+	}
+	if cache.Len() != 1 {
+		t.Errorf("Len() = %d, want 1", cache.Len())
+	}
+}
@@ -0,0 +1,211 @@
+package k8s
+
+import (
+	"context"
+	"fmt"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"mcp-db/pkg/types"
+	"sort"
+	"strings"
+)
+
+var ClusterDefinitionGVR = kubeBlocksGVR(APIVersionV1Alpha1, "clusterdefinitions")
+
+var ClusterVersionGVR = kubeBlocksGVR(APIVersionV1Alpha1, "clusterversions")
+
+// ComponentVersionGVR lists the serviceVersions of a ComponentDefinition in
+// the v1 API, which replaces ClusterVersions.
+var ComponentVersionGVR = kubeBlocksGVR(APIVersionV1, "componentversions")
+
+// UnknownVersionError is returned when the requested version is not
+// installed in the target cluster or not allowed by the registry.
+type UnknownVersionError struct {
+	Type    string
+	Version string
+	Valid   []string
+}
+
+func (e *UnknownVersionError) Error() string {
+	if len(e.Valid) == 0 {
+		return fmt.Sprintf("version %s of %s is not installed and no versions are available", e.Version, e.Type)
+	}
+	return fmt.Sprintf("version %s of %s is not installed, valid versions: %s", e.Version, e.Type, strings.Join(e.Valid, ", "))
+}
+
+// ListEngines returns the ClusterDefinitions installed in the target cluster
+// with their versions, marking the ones this service can create.
+func (c *Client) ListEngines(ctx context.Context) ([]types.EngineInfo, error) {
+	apiVersion, err := c.KubeBlocksAPIVersion()
+	if err != nil {
+		return nil, err
+	}
+	definitions, err := c.DynamicClient.Resource(kubeBlocksGVR(apiVersion, "clusterdefinitions")).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list ClusterDefinitions: %w", err)
+	}
+	clusterVersions := map[string][]string{}
+	if apiVersion == APIVersionV1Alpha1 {
+		clusterVersions, err = c.listClusterVersions(ctx, "")
+		if err != nil {
+			return nil, err
+		}
+	}
+	registry := Engines()
+	dbTypes := make(map[string]string, len(registry))
+	for dbType, dbConfig := range registry {
+		dbTypes[dbConfig.Definition] = dbType
+	}
+
+	result := make([]types.EngineInfo, 0, len(definitions.Items))
+	for _, definition := range definitions.Items {
+		name := definition.GetName()
+		engine := types.EngineInfo{
+			Definition:      name,
+			Type:            dbTypes[name],
+			APIVersion:      apiVersion,
+			ClusterVersions: clusterVersions[name],
+			Versions:        []string{},
+		}
+		if engine.ClusterVersions == nil {
+			engine.ClusterVersions = []string{}
+		}
+		if dbConfig, ok := registry[engine.Type]; ok {
+			engine.Supported = true
+			engine.DefaultVersion = dbConfig.DefaultVersion
+			installed := versionsFromNames(dbConfig.Version, engine.ClusterVersions)
+			if apiVersion == APIVersionV1 {
+				installed, err = c.listServiceVersions(ctx, dbConfig.Components[0].DefRef)
+				if err != nil {
+					return nil, err
+				}
+			}
+			engine.Versions = allowedVersions(dbConfig, installed)
+		}
+		result = append(result, engine)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Definition < result[j].Definition })
+	return result, nil
+}
+
+// ValidateVersion checks that version is allowed by the registry and is
+// installed in the target cluster, as a ClusterVersion for v1alpha1 or a
+// ComponentVersion release for v1. When it is not, an *UnknownVersionError
+// lists the versions that are.
+func (c *Client) ValidateVersion(ctx context.Context, dbType, version string) error {
+	dbConfig, ok := LookupEngine(dbType)
+	if !ok {
+		return fmt.Errorf("unsupported database type: %s", dbType)
+	}
+	if !dbConfig.AllowsVersion(version) {
+		return &UnknownVersionError{Type: dbType, Version: version, Valid: dbConfig.AllowedVersions}
+	}
+	apiVersion, err := c.KubeBlocksAPIVersion()
+	if err != nil {
+		return err
+	}
+	var installed []string
+	if apiVersion == APIVersionV1 {
+		installed, err = c.listServiceVersions(ctx, dbConfig.Components[0].DefRef)
+	} else {
+		var versions map[string][]string
+		versions, err = c.listClusterVersions(ctx, dbConfig.Definition)
+		installed = versionsFromNames(dbConfig.Version, versions[dbConfig.Definition])
+	}
+	if err != nil {
+		return err
+	}
+	valid := allowedVersions(dbConfig, installed)
+	for _, v := range valid {
+		if v == version {
+			return nil
+		}
+	}
+	return &UnknownVersionError{Type: dbType, Version: version, Valid: valid}
+}
+
+// allowedVersions filters installed versions by the registry allow list.
+func allowedVersions(dbConfig DatabaseConfig, installed []string) []string {
+	result := []string{}
+	for _, v := range installed {
+		if dbConfig.AllowsVersion(v) {
+			result = append(result, v)
+		}
+	}
+	return result
+}
+
+// listServiceVersions returns the serviceVersions released in the v1
+// ComponentVersion named after a ComponentDefinition.
+func (c *Client) listServiceVersions(ctx context.Context, name string) ([]string, error) {
+	componentVersion, err := c.DynamicClient.Resource(ComponentVersionGVR).Get(ctx, name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return []string{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get ComponentVersion %s: %w", name, err)
+	}
+	releases, _, _ := unstructured.NestedSlice(componentVersion.Object, "spec", "releases")
+	seen := map[string]bool{}
+	versions := []string{}
+	for _, r := range releases {
+		release, ok := r.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		version, _ := release["serviceVersion"].(string)
+		if version != "" && !seen[version] {
+			seen[version] = true
+			versions = append(versions, version)
+		}
+	}
+	sort.Strings(versions)
+	return versions, nil
+}
+
+// listClusterVersions returns ClusterVersion names grouped by the
+// ClusterDefinition they reference, optionally limited to one definition.
+func (c *Client) listClusterVersions(ctx context.Context, definition string) (map[string][]string, error) {
+	opts := metav1.ListOptions{}
+	if definition != "" {
+		opts.LabelSelector = fmt.Sprintf("clusterdefinition.kubeblocks.io/name=%s", definition)
+	}
+	list, err := c.DynamicClient.Resource(ClusterVersionGVR).List(ctx, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list ClusterVersions: %w", err)
+	}
+	result := map[string][]string{}
+	for _, version := range list.Items {
+		ref, _, _ := unstructured.NestedString(version.Object, "spec", "clusterDefinitionRef")
+		if ref == "" {
+			ref = version.GetLabels()["clusterdefinition.kubeblocks.io/name"]
+		}
+		if definition != "" && ref != definition {
+			continue
+		}
+		result[ref] = append(result[ref], version.GetName())
+	}
+	for ref := range result {
+		sort.Strings(result[ref])
+	}
+	return result, nil
+}
+
+// versionsFromNames strips the prefix and suffix of a version template such
+// as "postgresql-%s" from ClusterVersion names, yielding the values accepted
+// in the version field of a create request.
+func versionsFromNames(template string, names []string) []string {
+	prefix, suffix, _ := strings.Cut(template, "%s")
+	versions := make([]string, 0, len(names))
+	for _, name := range names {
+		if !strings.HasPrefix(name, prefix) || !strings.HasSuffix(name, suffix) {
+			continue
+		}
+		version := strings.TrimSuffix(strings.TrimPrefix(name, prefix), suffix)
+		if version != "" {
+			versions = append(versions, version)
+		}
+	}
+	return versions
+}
@@ -1,41 +1,69 @@
 package k8s
 
 import (
+	"context"
 	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/clientcmd"
-	"log"
+	"mcp-db/internal/metrics"
+	"os"
+	"strings"
+	"sync"
 )
 
+// serviceAccountNamespaceFile holds the namespace of a pod's service account.
+const serviceAccountNamespaceFile = "/var/run/secrets/kubernetes.io/serviceaccount/namespace"
+
 type Client struct {
-	ClientSet     *kubernetes.Clientset
+	ClientSet     kubernetes.Interface
 	DynamicClient dynamic.Interface
+
+	config *rest.Config
+
+	// apiVersion is the discovered KubeBlocks API version, empty until a
+	// discovery succeeds.
+	apiVersionMu sync.Mutex
+	apiVersion   string
 }
 
 func NewClient(kubeconfig string) (*Client, error) {
 	cfg, err := NewConfigFromString(kubeconfig)
-	if err != nil {
-		log.Fatal(err)
-	}
-	clientSet, err := kubernetes.NewForConfig(cfg)
 	if err != nil {
 		return nil, err
 	}
-	dynamicClient, err := dynamic.NewForConfig(cfg)
+	return NewClientForConfig(cfg)
+}
+
+// NewClientForConfig builds a client from a rest config. Its requests are
+// measured by the metrics package.
+func NewClientForConfig(cfg *rest.Config) (*Client, error) {
+	instrumented := rest.CopyConfig(cfg)
+	instrumented.Wrap(metrics.InstrumentKubernetes)
+	clientSet, err := kubernetes.NewForConfig(instrumented)
 	if err != nil {
 		return nil, err
 	}
-	dynamicClient, err = dynamic.NewForConfig(cfg)
+	dynamicClient, err := dynamic.NewForConfig(instrumented)
 	if err != nil {
 		return nil, err
 	}
 	return &Client{
 		ClientSet:     clientSet,
 		DynamicClient: dynamicClient,
+		config:        cfg,
 	}, nil
 }
 
+// NewImpersonatingClient builds a client that acts as user through
+// Kubernetes impersonation on top of base, which must be allowed to
+// impersonate.
+func NewImpersonatingClient(base *rest.Config, user rest.ImpersonationConfig) (*Client, error) {
+	cfg := rest.CopyConfig(base)
+	cfg.Impersonate = user
+	return NewClientForConfig(cfg)
+}
+
 func NewConfigFromString(kubeconfig string) (*rest.Config, error) {
 	config, err := clientcmd.RESTConfigFromKubeConfig([]byte(kubeconfig))
 	if err != nil {
@@ -43,3 +71,37 @@ func NewConfigFromString(kubeconfig string) (*rest.Config, error) {
 	}
 	return config, nil
 }
+
+// NewInClusterClient builds a client from the service account of the pod the
+// service runs in.
+func NewInClusterClient() (*Client, error) {
+	cfg, err := rest.InClusterConfig()
+	if err != nil {
+		return nil, err
+	}
+	return NewClientForConfig(cfg)
+}
+
+// Config returns the rest config the client was built from.
+func (c *Client) Config() *rest.Config {
+	return c.config
+}
+
+// InClusterNamespace returns the namespace the service runs in, from the
+// POD_NAMESPACE variable or the service account, defaulting to "default".
+func InClusterNamespace() string {
+	if namespace := os.Getenv("POD_NAMESPACE"); namespace != "" {
+		return namespace
+	}
+	if data, err := os.ReadFile(serviceAccountNamespaceFile); err == nil {
+		if namespace := strings.TrimSpace(string(data)); namespace != "" {
+			return namespace
+		}
+	}
+	return "default"
+}
+
+// Ping checks that the API server is reachable and ready.
+func (c *Client) Ping(ctx context.Context) error {
+	return c.ClientSet.Discovery().RESTClient().Get().AbsPath("/readyz").Do(ctx).Error()
+}
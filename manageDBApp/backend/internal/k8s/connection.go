@@ -0,0 +1,216 @@
+package k8s
+
+import (
+	"context"
+	"fmt"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"net"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// connectionFormats describes how clients of each engine connect. Engines
+// without an entry get a plain URI using their type as the scheme.
+var connectionFormats = map[string]connectionFormat{
+	"postgresql": {scheme: "postgresql", database: "postgres", jdbc: "postgresql", account: "postgres"},
+	"mysql":      {scheme: "mysql", jdbc: "mysql", account: "root"},
+	"redis":      {scheme: "redis", database: "0", account: "default"},
+	"mongodb":    {scheme: "mongodb", database: "admin", query: "authSource=admin", account: "root"},
+}
+
+type connectionFormat struct {
+	scheme   string
+	database string
+	query    string
+	jdbc     string
+	// account is the system account clients connect as on v1 clusters,
+	// whose Secret is named after it.
+	account string
+}
+
+// Connection holds the ways to reach one address of a database.
+type Connection struct {
+	Database string
+	URI      string
+	JDBCURL  string
+	CLI      string
+}
+
+// BuildConnection renders the URI, JDBC URL and CLI command for a database
+// of type dbType, escaping the credentials for each format.
+func BuildConnection(dbType, host, port, username, password string) Connection {
+	format, ok := connectionFormats[dbType]
+	if !ok {
+		format = connectionFormat{scheme: dbType}
+	}
+	address := net.JoinHostPort(host, port)
+	uri := url.URL{
+		Scheme:   format.scheme,
+		User:     url.UserPassword(username, password),
+		Host:     address,
+		Path:     "/" + format.database,
+		RawQuery: format.query,
+	}
+	conn := Connection{Database: format.database, URI: uri.String()}
+	if format.jdbc != "" {
+		query := url.Values{"user": {username}, "password": {password}}
+		conn.JDBCURL = "jdbc:" + format.jdbc + "://" + address + "/" + format.database + "?" + query.Encode()
+	}
+	switch dbType {
+	case "postgresql":
+		conn.CLI = "PGPASSWORD=" + shellQuote(password) + " psql -h " + shellQuote(host) + " -p " + port + " -U " + shellQuote(username) + " -d " + format.database
+	case "mysql":
+		conn.CLI = "mysql -h " + shellQuote(host) + " -P " + port + " -u " + shellQuote(username) + " -p" + shellQuote(password)
+	case "redis":
+		conn.CLI = "redis-cli -h " + shellQuote(host) + " -p " + port + " --user " + shellQuote(username) + " --pass " + shellQuote(password)
+	case "mongodb":
+		conn.CLI = "mongosh " + shellQuote(conn.URI)
+	}
+	return conn
+}
+
+// shellQuote wraps s in single quotes for POSIX shells.
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'"'"'`) + "'"
+}
+
+// ClusterEngine returns the registry type of a cluster, or its
+// ClusterDefinition name when the registry does not know it.
+func (c *Client) ClusterEngine(ctx context.Context, name, namespace string) (string, error) {
+	cluster, err := c.getCluster(ctx, name, namespace)
+	if err != nil {
+		return "", err
+	}
+	return clusterEngine(cluster)
+}
+
+func (c *Client) getCluster(ctx context.Context, name, namespace string) (*unstructured.Unstructured, error) {
+	gvr, err := c.clusterGVR()
+	if err != nil {
+		return nil, err
+	}
+	cluster, err := c.DynamicClient.Resource(gvr).Namespace(namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get cluster %s: %w", name, err)
+	}
+	return cluster, nil
+}
+
+func clusterEngine(cluster *unstructured.Unstructured) (string, error) {
+	info, err := parseClusterInfo(cluster)
+	if err != nil {
+		return "", err
+	}
+	if dbType, ok := EngineForDefinition(info.Type); ok {
+		return dbType, nil
+	}
+	return info.Type, nil
+}
+
+// ConnectionSecretName returns the name of the Secret KubeBlocks creates
+// with the credentials of a cluster. v1alpha1 keeps one Secret per cluster;
+// v1 keeps one per account of each component.
+func ConnectionSecretName(apiVersion, cluster, component, account string) string {
+	if apiVersion == APIVersionV1Alpha1 {
+		return cluster + "-conn-credential"
+	}
+	return cluster + "-" + component + "-account-" + account
+}
+
+// ConnectionCredentials are the admin credentials of a cluster and the
+// in-cluster Service of the component clients connect to.
+type ConnectionCredentials struct {
+	Type      string
+	Component string
+	// Service is the name of the Service in the cluster namespace.
+	Service  string
+	Port     string
+	Username string
+	Password string
+	// Secret holds Username and Password.
+	Secret *corev1.Secret
+}
+
+// GetConnectionCredentials reads the admin credentials of a cluster from
+// the Secret of the KubeBlocks API version it is served by. v1alpha1 keeps
+// the Service and port in the Secret; on v1 the Secret of the system account
+// of the first component is read, and the Service is the ClusterIP Service
+// of that component.
+func (c *Client) GetConnectionCredentials(ctx context.Context, name, namespace string) (*ConnectionCredentials, error) {
+	apiVersion, err := c.KubeBlocksAPIVersion()
+	if err != nil {
+		return nil, err
+	}
+	cluster, err := c.getCluster(ctx, name, namespace)
+	if err != nil {
+		return nil, err
+	}
+	creds := &ConnectionCredentials{}
+	if creds.Type, err = clusterEngine(cluster); err != nil {
+		return nil, err
+	}
+	account := ""
+	if apiVersion != APIVersionV1Alpha1 {
+		if creds.Component, err = connectionComponent(cluster); err != nil {
+			return nil, err
+		}
+		account = connectionFormats[creds.Type].account
+		if account == "" {
+			return nil, &InvalidRequestError{Err: fmt.Errorf("no connection account is known for %s clusters", creds.Type)}
+		}
+	}
+	secretName := ConnectionSecretName(apiVersion, name, creds.Component, account)
+	creds.Secret, err = c.ClientSet.CoreV1().Secrets(namespace).Get(ctx, secretName, metav1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get connection secret %s: %w", secretName, err)
+	}
+	creds.Username = string(creds.Secret.Data["username"])
+	creds.Password = string(creds.Secret.Data["password"])
+	if apiVersion == APIVersionV1Alpha1 {
+		creds.Service = string(creds.Secret.Data["host"])
+		creds.Port = string(creds.Secret.Data["port"])
+		creds.Component = strings.TrimPrefix(creds.Service, name+"-")
+	} else if creds.Service, creds.Port, err = c.componentService(ctx, name, namespace, creds.Component); err != nil {
+		return nil, err
+	}
+	if creds.Service == "" || creds.Port == "" {
+		return nil, fmt.Errorf("connection secret %s has no host or port", secretName)
+	}
+	return creds, nil
+}
+
+// connectionComponent returns the name of the first componentSpecs entry of
+// a cluster, the component clients connect to.
+func connectionComponent(cluster *unstructured.Unstructured) (string, error) {
+	specs, _, _ := unstructured.NestedSlice(cluster.Object, "spec", "componentSpecs")
+	for _, spec := range specs {
+		if component, ok := spec.(map[string]interface{}); ok {
+			if name, _ := component["name"].(string); name != "" {
+				return name, nil
+			}
+		}
+	}
+	return "", fmt.Errorf("cluster %s has no component to connect to", cluster.GetName())
+}
+
+// componentService returns the first, by name, ClusterIP Service of a
+// component that is not headless, and its first port.
+func (c *Client) componentService(ctx context.Context, name, namespace, component string) (string, string, error) {
+	selector := "app.kubernetes.io/instance=" + name + ",apps.kubeblocks.io/component-name=" + component
+	services, err := c.ClientSet.CoreV1().Services(namespace).List(ctx, metav1.ListOptions{LabelSelector: selector})
+	if err != nil {
+		return "", "", fmt.Errorf("failed to list services of %s: %w", name, err)
+	}
+	sort.Slice(services.Items, func(i, j int) bool { return services.Items[i].Name < services.Items[j].Name })
+	for _, service := range services.Items {
+		if service.Spec.Type != corev1.ServiceTypeClusterIP || service.Spec.ClusterIP == corev1.ClusterIPNone || len(service.Spec.Ports) == 0 {
+			continue
+		}
+		return service.Name, strconv.Itoa(int(service.Spec.Ports[0].Port)), nil
+	}
+	return "", "", fmt.Errorf("component %s of cluster %s has no ClusterIP service", component, name)
+}
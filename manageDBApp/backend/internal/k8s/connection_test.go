@@ -0,0 +1,146 @@
+package k8s
+
+import (
+	"context"
+	"errors"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+	"testing"
+)
+
+// newKubeBlocksClient returns a Client of a cluster serving KubeBlocks
+// Clusters in apiVersion, holding a rendered postgresql cluster named
+// "render" in namespace "tenant" and objects.
+func newKubeBlocksClient(t *testing.T, apiVersion string, objects ...runtime.Object) (*Client, *fake.Clientset) {
+	t.Helper()
+	return newEngineClient(t, "postgresql", apiVersion, objects...)
+}
+
+// newEngineClient is newKubeBlocksClient for a cluster of dbType.
+func newEngineClient(t *testing.T, dbType, apiVersion string, objects ...runtime.Object) (*Client, *fake.Clientset) {
+	t.Helper()
+	dbConfig := DatabaseConfigs[dbType]
+	topology, err := ResolveTopology(dbConfig, "", 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cluster := buildCluster(renderRequest(dbType), dbConfig, topology, apiVersion, dbConfig.DefaultVersion, DefaultTerminationPolicy)
+	clientSet := fake.NewSimpleClientset(objects...)
+	clientSet.Resources = []*metav1.APIResourceList{{
+		GroupVersion: KubeBlocksGroup + "/" + apiVersion,
+		APIResources: []metav1.APIResource{{Name: "clusters", Kind: "Cluster", Namespaced: true}},
+	}}
+	dynamicClient := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(),
+		map[schema.GroupVersionResource]string{kubeBlocksGVR(apiVersion, "clusters"): "ClusterList"}, cluster)
+	return &Client{ClientSet: clientSet, DynamicClient: dynamicClient}, clientSet
+}
+
+func credentialSecret(name string, data map[string]string) *corev1.Secret {
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "tenant"},
+		Data:       map[string][]byte{},
+	}
+	for k, v := range data {
+		secret.Data[k] = []byte(v)
+	}
+	return secret
+}
+
+func componentService(name, clusterIP string, port int32) *corev1.Service {
+	return &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: "tenant",
+			Labels: map[string]string{
+				"app.kubernetes.io/instance":        "render",
+				"apps.kubeblocks.io/component-name": "postgresql",
+			},
+		},
+		Spec: corev1.ServiceSpec{
+			Type:      corev1.ServiceTypeClusterIP,
+			ClusterIP: clusterIP,
+			Ports:     []corev1.ServicePort{{Port: port}},
+		},
+	}
+}
+
+func TestGetConnectionCredentials(t *testing.T) {
+	tests := []struct {
+		apiVersion string
+		objects    []runtime.Object
+		want       ConnectionCredentials
+	}{
+		{
+			apiVersion: APIVersionV1Alpha1,
+			objects: []runtime.Object{
+				credentialSecret("render-conn-credential", map[string]string{
+					"host": "render-postgresql", "port": "5432", "username": "postgres", "password": "old",
+				}),
+			},
+			want: ConnectionCredentials{Type: "postgresql", Component: "postgresql", Service: "render-postgresql", Port: "5432", Username: "postgres", Password: "old"},
+		},
+		{
+			apiVersion: APIVersionV1,
+			objects: []runtime.Object{
+				credentialSecret("render-postgresql-account-postgres", map[string]string{"username": "postgres", "password": "new"}),
+				componentService("render-postgresql-headless", corev1.ClusterIPNone, 5432),
+				componentService("render-postgresql-postgresql", "10.0.0.10", 5432),
+			},
+			want: ConnectionCredentials{Type: "postgresql", Component: "postgresql", Service: "render-postgresql-postgresql", Port: "5432", Username: "postgres", Password: "new"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.apiVersion, func(t *testing.T) {
+			c, _ := newKubeBlocksClient(t, tt.apiVersion, tt.objects...)
+			creds, err := c.GetConnectionCredentials(context.Background(), "render", "tenant")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if creds.Secret == nil {
+				t.Fatal("Secret not returned")
+			}
+			got := *creds
+			got.Secret = nil
+			if got != tt.want {
+				t.Errorf("GetConnectionCredentials() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetConnectionCredentialsV1WithoutAccountSecret(t *testing.T) {
+	// The v1alpha1 Secret is not read on a v1 cluster.
+	c, _ := newKubeBlocksClient(t, APIVersionV1,
+		credentialSecret("render-conn-credential", map[string]string{"host": "render-postgresql", "port": "5432"}),
+		componentService("render-postgresql-postgresql", "10.0.0.10", 5432))
+	if _, err := c.GetConnectionCredentials(context.Background(), "render", "tenant"); err == nil {
+		t.Fatal("GetConnectionCredentials() succeeded without the account secret")
+	}
+}
+
+func TestKubeBlocksAPIVersionRetriesAfterError(t *testing.T) {
+	c, clientSet := newKubeBlocksClient(t, APIVersionV1)
+	failures := 1
+	clientSet.PrependReactor("get", "resource", func(k8stesting.Action) (bool, runtime.Object, error) {
+		if failures == 0 {
+			return false, nil, nil
+		}
+		failures--
+		return true, nil, errors.New("discovery timed out")
+	})
+	if _, err := c.KubeBlocksAPIVersion(); err == nil {
+		t.Fatal("KubeBlocksAPIVersion() succeeded despite the discovery error")
+	}
+	version, err := c.KubeBlocksAPIVersion()
+	if err != nil {
+		t.Fatalf("KubeBlocksAPIVersion() after a failure = %v, want a new discovery", err)
+	}
+	if version != APIVersionV1 {
+		t.Errorf("KubeBlocksAPIVersion() = %q, want %q", version, APIVersionV1)
+	}
+}
@@ -0,0 +1,316 @@
+package k8s
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"log/slog"
+	"math/big"
+	"mcp-db/pkg/types"
+	"sort"
+	"strings"
+	"time"
+)
+
+// CredentialsRotatedAnnotation records on the connection secret when its
+// password was last rotated.
+const CredentialsRotatedAnnotation = "sealos.io/credentials-rotated-at"
+
+// opsRequestTTL is how long KubeBlocks keeps a succeeded restart
+// OpsRequest.
+const opsRequestTTL = time.Hour
+
+// passwordLength and passwordAlphabet shape generated passwords. The
+// alphabet has no quotes so the password can be embedded in SQL as is.
+const (
+	passwordLength   = 32
+	passwordAlphabet = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
+)
+
+// passwordChangers run statements inside the database container as a user.
+// The credentials and statements are written to the stdin of a fixed
+// command, so they appear neither in the exec request nor in the process
+// list of the container.
+var passwordChangers = map[string]passwordChanger{
+	"postgresql": {
+		container: "postgresql",
+		command: []string{"sh", "-c", `IFS= read -r PGUSER && IFS= read -r PGPASSWORD && export PGUSER PGPASSWORD && ` +
+			`exec psql -h 127.0.0.1 -d postgres -q -v ON_ERROR_STOP=1`},
+		input: lineInput,
+		alter: func(username, password string) string {
+			return fmt.Sprintf(`ALTER USER "%s" WITH PASSWORD '%s';`, strings.ReplaceAll(username, `"`, `""`), password)
+		},
+		ping: "SELECT 1;",
+	},
+	"mysql": {
+		container: "mysql",
+		command: []string{"sh", "-c", `IFS= read -r user && IFS= read -r MYSQL_PWD && export MYSQL_PWD && ` +
+			`exec mysql -h127.0.0.1 -u"$user"`},
+		input: lineInput,
+		alter: func(username, password string) string {
+			user := strings.ReplaceAll(username, "'", "''")
+			return fmt.Sprintf(`ALTER USER IF EXISTS '%s'@'%%' IDENTIFIED BY '%s', '%s'@'localhost' IDENTIFIED BY '%s';`, user, password, user, password)
+		},
+		ping: "SELECT 1;",
+	},
+	"mongodb": {
+		container: "mongodb",
+		// mongosh only exits non-zero on an error in a script file.
+		command: []string{"sh", "-c", `umask 077 && f=$(mktemp) && trap 'rm -f "$f"' EXIT && cat > "$f" && ` +
+			`mongosh --quiet --norc --host 127.0.0.1 "$f"`},
+		input: func(username, password, statement string) string {
+			return fmt.Sprintf("const admin = db.getSiblingDB(\"admin\");\nadmin.auth(%s, %s);\n%s\n", jsString(username), jsString(password), statement)
+		},
+		alter: func(username, password string) string {
+			return fmt.Sprintf("admin.changeUserPassword(%s, %s);", jsString(username), jsString(password))
+		},
+		ping: "admin.runCommand({connectionStatus: 1});",
+	},
+}
+
+type passwordChanger struct {
+	container string
+	command   []string
+	// input renders the stdin of command that authenticates as username
+	// with password and runs statement.
+	input func(username, password, statement string) string
+	// alter renders the statement that sets the password of username.
+	alter func(username, password string) string
+	// ping is a statement that succeeds once authenticated.
+	ping string
+}
+
+// lineInput passes the username and password as the first two lines of
+// stdin, followed by the statement.
+func lineInput(username, password, statement string) string {
+	return username + "\n" + password + "\n" + statement + "\n"
+}
+
+func jsString(s string) string {
+	quoted, _ := json.Marshal(s)
+	return string(quoted)
+}
+
+// run executes statement in the database of pod as username.
+func (p passwordChanger) run(ctx context.Context, c *Client, namespace, pod, username, password, statement string) error {
+	_, err := c.execInPod(ctx, namespace, pod, p.container, p.command, p.input(username, password, statement))
+	return err
+}
+
+// changePassword changes the password of username from one value to
+// another. The exec stream can break after the database applied the change,
+// so when it fails the new password is tried before reporting the error.
+func (p passwordChanger) changePassword(ctx context.Context, c *Client, namespace, pod, username, from, to string) error {
+	err := p.run(ctx, c, namespace, pod, username, from, p.alter(username, to))
+	if err == nil {
+		return nil
+	}
+	if pingErr := p.run(context.WithoutCancel(ctx), c, namespace, pod, username, to, p.ping); pingErr == nil {
+		slog.WarnContext(ctx, "Password change reported an error but the new password is in effect", "namespace", namespace, "pod", pod, "error", err)
+		return nil
+	}
+	return err
+}
+
+// RotateCredentials sets a new generated password for the admin user of a
+// cluster. The password is changed inside the database first, on the pod
+// clients connect to, then written to the connection secret with an update
+// that fails if the secret changed meanwhile. If the secret cannot be
+// updated the database password is changed back, so the old password keeps
+// working whenever the rotation fails. A password change whose exec fails is
+// checked against the database before it is reported as failed.
+//
+// Pods that read the secret into their environment, such as the probes,
+// sidecars and exporters of KubeBlocks, keep the old password until they
+// restart. Their components are restarted through a Restart OpsRequest
+// once the secret is updated; when that fails the rotation still succeeds
+// and the response carries a warning. Engines without a password changer
+// are an *InvalidRequestError.
+func (c *Client) RotateCredentials(ctx context.Context, name, namespace string) (*types.RotateCredentialsResponse, error) {
+	dbType, err := c.ClusterEngine(ctx, name, namespace)
+	if err != nil {
+		return nil, err
+	}
+	changer, ok := passwordChangers[dbType]
+	if !ok {
+		return nil, &InvalidRequestError{Err: fmt.Errorf("credential rotation is not supported for %s clusters, only for %s", dbType, strings.Join(RotatableEngines(), ", "))}
+	}
+	creds, err := c.GetConnectionCredentials(ctx, name, namespace)
+	if err != nil {
+		return nil, err
+	}
+	secrets := c.ClientSet.CoreV1().Secrets(namespace)
+	secret := creds.Secret
+	username := creds.Username
+	current := creds.Password
+	if username == "" || current == "" {
+		return nil, fmt.Errorf("connection secret of %s has no username or password", name)
+	}
+	pod, err := c.writablePod(ctx, name, namespace, creds.Component)
+	if err != nil {
+		return nil, err
+	}
+	next, err := generatePassword()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := changer.changePassword(ctx, c, namespace, pod, username, current, next); err != nil {
+		return nil, fmt.Errorf("failed to change password in database: %w", err)
+	}
+	rotatedAt := time.Now().UTC()
+	secret.Data["password"] = []byte(next)
+	if secret.Annotations == nil {
+		secret.Annotations = map[string]string{}
+	}
+	secret.Annotations[CredentialsRotatedAnnotation] = rotatedAt.Format(time.RFC3339)
+	if _, err := secrets.Update(ctx, secret, metav1.UpdateOptions{}); err != nil {
+		if revertErr := changer.changePassword(context.WithoutCancel(ctx), c, namespace, pod, username, next, current); revertErr != nil {
+			return nil, fmt.Errorf("failed to update connection secret: %v; restoring the old password also failed: %w", err, revertErr)
+		}
+		return nil, fmt.Errorf("failed to update connection secret, the old password is still valid: %w", err)
+	}
+	rotation := &types.RotateCredentialsResponse{
+		Name:      name,
+		Username:  username,
+		RotatedAt: rotatedAt.Format(time.RFC3339),
+	}
+	// The password is rotated now; the restart runs even if the caller
+	// goes away.
+	if err := c.restartSecretReaders(context.WithoutCancel(ctx), name, namespace, secret.Name, rotatedAt, rotation); err != nil {
+		slog.WarnContext(ctx, "Failed to restart the pods reading the old password", "namespace", namespace, "name", name, "error", err)
+		rotation.Warning = fmt.Sprintf("pods reading the connection secret keep the old password until they restart: %v", err)
+	}
+	return rotation, nil
+}
+
+// RotatableEngines returns, sorted, the database types whose credentials
+// can be rotated.
+func RotatableEngines() []string {
+	dbTypes := make([]string, 0, len(passwordChangers))
+	for dbType := range passwordChangers {
+		dbTypes = append(dbTypes, dbType)
+	}
+	sort.Strings(dbTypes)
+	return dbTypes
+}
+
+// restartSecretReaders restarts the components of a cluster whose pods read
+// secret through env or envFrom, recording them and the OpsRequest in
+// rotation. Mounted secrets are refreshed by the kubelet and need no
+// restart.
+func (c *Client) restartSecretReaders(ctx context.Context, name, namespace, secret string, at time.Time, rotation *types.RotateCredentialsResponse) error {
+	pods, err := c.ClientSet.CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{LabelSelector: "app.kubernetes.io/instance=" + name})
+	if err != nil {
+		return fmt.Errorf("failed to list pods: %w", err)
+	}
+	var components []string
+	for _, pod := range pods.Items {
+		component := pod.Labels["apps.kubeblocks.io/component-name"]
+		if component != "" && !contains(components, component) && readsSecretFromEnv(&pod.Spec, secret) {
+			components = append(components, component)
+		}
+	}
+	if len(components) == 0 {
+		return nil
+	}
+	sort.Strings(components)
+	apiVersion, err := c.KubeBlocksAPIVersion()
+	if err != nil {
+		return err
+	}
+	restart := make([]interface{}, 0, len(components))
+	for _, component := range components {
+		restart = append(restart, map[string]interface{}{"componentName": component})
+	}
+	// v1alpha1 OpsRequests name their cluster with clusterRef.
+	clusterField := "clusterName"
+	if apiVersion == APIVersionV1Alpha1 {
+		clusterField = "clusterRef"
+	}
+	gvr := opsRequestGVR(apiVersion)
+	ops := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": gvr.GroupVersion().String(),
+		"kind":       "OpsRequest",
+		"metadata": map[string]interface{}{
+			"name":      fmt.Sprintf("%s-rotate-credentials-%d", name, at.Unix()),
+			"namespace": namespace,
+			"labels":    map[string]interface{}{"app.kubernetes.io/instance": name},
+		},
+		"spec": map[string]interface{}{
+			clusterField:             name,
+			"type":                   "Restart",
+			"restart":                restart,
+			"ttlSecondsAfterSucceed": int64(opsRequestTTL.Seconds()),
+		},
+	}}
+	created, err := c.DynamicClient.Resource(gvr).Namespace(namespace).Create(ctx, ops, metav1.CreateOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to restart components %s: %w", strings.Join(components, ", "), err)
+	}
+	rotation.RestartedComponents = components
+	rotation.OpsRequest = created.GetName()
+	return nil
+}
+
+// readsSecretFromEnv reports whether a container of spec takes a variable
+// from secret.
+func readsSecretFromEnv(spec *corev1.PodSpec, secret string) bool {
+	containers := append(append([]corev1.Container{}, spec.InitContainers...), spec.Containers...)
+	for _, container := range containers {
+		for _, env := range container.Env {
+			if env.ValueFrom != nil && env.ValueFrom.SecretKeyRef != nil && env.ValueFrom.SecretKeyRef.Name == secret {
+				return true
+			}
+		}
+		for _, from := range container.EnvFrom {
+			if from.SecretRef != nil && from.SecretRef.Name == secret {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// writablePod returns the pod of component that accepts writes: the one
+// with a writable role, or the only running pod when the component has no
+// roles.
+func (c *Client) writablePod(ctx context.Context, name, namespace, component string) (string, error) {
+	pods, err := c.listPodRoles(ctx, namespace, name)
+	if err != nil {
+		return "", err
+	}
+	running := ""
+	for _, pod := range pods[name] {
+		if pod.Component != component {
+			continue
+		}
+		if contains(writableRoles, pod.Role) {
+			return pod.Name, nil
+		}
+		if pod.Status == "Running" && running == "" {
+			running = pod.Name
+		}
+	}
+	if running == "" {
+		return "", fmt.Errorf("no running pod of component %s in cluster %s", component, name)
+	}
+	return running, nil
+}
+
+func generatePassword() (string, error) {
+	size := big.NewInt(int64(len(passwordAlphabet)))
+	password := make([]byte, passwordLength)
+	for i := range password {
+		n, err := rand.Int(rand.Reader, size)
+		if err != nil {
+			return "", fmt.Errorf("failed to generate password: %w", err)
+		}
+		password[i] = passwordAlphabet[n.Int64()]
+	}
+	return string(password), nil
+}
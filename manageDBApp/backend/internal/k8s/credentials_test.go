@@ -0,0 +1,101 @@
+package k8s
+
+import (
+	"context"
+	"errors"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"mcp-db/pkg/types"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRotateCredentialsUnsupportedEngine(t *testing.T) {
+	c, _ := newEngineClient(t, "redis", APIVersionV1)
+	_, err := c.RotateCredentials(context.Background(), "render", "tenant")
+	var invalid *InvalidRequestError
+	if !errors.As(err, &invalid) {
+		t.Fatalf("RotateCredentials() = %v, want an *InvalidRequestError", err)
+	}
+	if !strings.Contains(err.Error(), "redis") {
+		t.Errorf("RotateCredentials() = %q, want it to name the engine", err)
+	}
+}
+
+// secretPod is a pod of component whose container reads secret through env
+// when env is set, or mounts it otherwise.
+func secretPod(name, component, secret string, env bool) *corev1.Pod {
+	container := corev1.Container{Name: component}
+	var volumes []corev1.Volume
+	if env {
+		container.Env = []corev1.EnvVar{{
+			Name: "PASSWORD",
+			ValueFrom: &corev1.EnvVarSource{SecretKeyRef: &corev1.SecretKeySelector{
+				LocalObjectReference: corev1.LocalObjectReference{Name: secret},
+				Key:                  "password",
+			}},
+		}}
+	} else {
+		volumes = []corev1.Volume{{Name: "credentials", VolumeSource: corev1.VolumeSource{Secret: &corev1.SecretVolumeSource{SecretName: secret}}}}
+	}
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: "tenant",
+			Labels: map[string]string{
+				"app.kubernetes.io/instance":        "render",
+				"apps.kubeblocks.io/component-name": component,
+			},
+		},
+		Spec: corev1.PodSpec{Containers: []corev1.Container{container}, Volumes: volumes},
+	}
+}
+
+func TestRestartSecretReaders(t *testing.T) {
+	for _, apiVersion := range []string{APIVersionV1Alpha1, APIVersionV1} {
+		t.Run(apiVersion, func(t *testing.T) {
+			secret := ConnectionSecretName(apiVersion, "render", "postgresql", "postgres")
+			c, _ := newKubeBlocksClient(t, apiVersion,
+				secretPod("render-postgresql-0", "postgresql", secret, true),
+				secretPod("render-postgresql-1", "postgresql", secret, true),
+				secretPod("render-exporter-0", "exporter", secret, false),
+				secretPod("render-other-0", "other", "unrelated", true))
+			at := time.Unix(1700000000, 0)
+			rotation := &types.RotateCredentialsResponse{}
+			if err := c.restartSecretReaders(context.Background(), "render", "tenant", secret, at, rotation); err != nil {
+				t.Fatal(err)
+			}
+			if want := []string{"postgresql"}; !reflect.DeepEqual(rotation.RestartedComponents, want) {
+				t.Errorf("RestartedComponents = %v, want %v", rotation.RestartedComponents, want)
+			}
+			ops, err := c.DynamicClient.Resource(opsRequestGVR(apiVersion)).Namespace("tenant").Get(context.Background(), rotation.OpsRequest, metav1.GetOptions{})
+			if err != nil {
+				t.Fatalf("OpsRequest %q not created: %v", rotation.OpsRequest, err)
+			}
+			clusterField := "clusterName"
+			if apiVersion == APIVersionV1Alpha1 {
+				clusterField = "clusterRef"
+			}
+			if cluster, _, _ := unstructured.NestedString(ops.Object, "spec", clusterField); cluster != "render" {
+				t.Errorf("spec.%s = %q, want render", clusterField, cluster)
+			}
+			if opsType, _, _ := unstructured.NestedString(ops.Object, "spec", "type"); opsType != "Restart" {
+				t.Errorf("spec.type = %q, want Restart", opsType)
+			}
+		})
+	}
+}
+
+func TestRestartSecretReadersWithoutReaders(t *testing.T) {
+	c, _ := newKubeBlocksClient(t, APIVersionV1, secretPod("render-postgresql-0", "postgresql", "other", true))
+	rotation := &types.RotateCredentialsResponse{}
+	if err := c.restartSecretReaders(context.Background(), "render", "tenant", "render-postgresql-account-postgres", time.Now(), rotation); err != nil {
+		t.Fatal(err)
+	}
+	if rotation.OpsRequest != "" || rotation.RestartedComponents != nil {
+		t.Errorf("restartSecretReaders() = %+v, want no restart", rotation)
+	}
+}
@@ -2,285 +2,671 @@ package k8s
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
-	"log"
+	"log/slog"
 	"mcp-db/pkg/types"
-	"time"
+	"strconv"
+	"strings"
 
+	rbacv1 "k8s.io/api/rbac/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
-	"k8s.io/apimachinery/pkg/runtime/schema"
+	k8stypes "k8s.io/apimachinery/pkg/types"
 )
 
-var DatabaseClusterGVR = schema.GroupVersionResource{
-	Group:    "apps.kubeblocks.io",
-	Version:  "v1alpha1",
-	Resource: "clusters",
+// DatabaseClusterGVR is the v1alpha1 Cluster resource. Use clusterGVR to
+// address Clusters in the version served by the target cluster.
+var DatabaseClusterGVR = kubeBlocksGVR(APIVersionV1Alpha1, "clusters")
+
+const (
+	TerminationPolicyDoNotTerminate = "DoNotTerminate"
+	TerminationPolicyHalt           = "Halt"
+	TerminationPolicyDelete         = "Delete"
+	TerminationPolicyWipeOut        = "WipeOut"
+
+	DefaultTerminationPolicy = TerminationPolicyDelete
+)
+
+// TerminationPolicies lists the terminationPolicy values accepted by KubeBlocks.
+var TerminationPolicies = []string{
+	TerminationPolicyDoNotTerminate,
+	TerminationPolicyHalt,
+	TerminationPolicyDelete,
+	TerminationPolicyWipeOut,
 }
 
-var DatabaseConfigs = map[string]struct {
-	Definition string
-	Version    string
-	Component  string
-}{
-	"postgresql": {
-		Definition: "postgresql",
-		Version:    "postgresql-%s",
-		Component:  "postgresql",
-	},
-	"mysql": {
-		Definition: "apecloud-mysql",
-		Version:    "ac-mysql-%s",
-		Component:  "mysql",
-	},
-	"redis": {
-		Definition: "redis",
-		Version:    "redis-%s",
-		Component:  "redis",
-	},
-	"mongodb": {
-		Definition: "mongodb",
-		Version:    "mongodb-%s",
-		Component:  "mongodb",
-	},
+// ValidTerminationPolicy reports whether policy is a known terminationPolicy.
+func ValidTerminationPolicy(policy string) bool {
+	for _, p := range TerminationPolicies {
+		if p == policy {
+			return true
+		}
+	}
+	return false
 }
 
-var DefaultVersions = map[string]string{
-	"postgresql": "14.8.0",
-	"mysql":      "8.0.30",
-	"redis":      "7.0.6",
-	"mongodb":    "6.0",
+// TerminationPolicyRemovesData reports whether deleting a cluster with the
+// given policy also removes its persistent data.
+func TerminationPolicyRemovesData(policy string) bool {
+	return policy == TerminationPolicyDelete || policy == TerminationPolicyWipeOut
 }
 
-func (c *Client) CreateDatabaseCluster(ctx context.Context, req *types.CreateDatabaseRequest) error {
-	dbConfig, ok := DatabaseConfigs[req.Type]
+// CreateDatabaseCluster creates the cluster described by req. It is safe to
+// retry: if a cluster with the same name and spec already exists it is
+// returned with created set to false, and if the spec differs a
+// *SpecConflictError is returned. RBAC objects with the cluster's name that
+// were not created for it are an *RBACConflictError.
+func (c *Client) CreateDatabaseCluster(ctx context.Context, req *types.CreateDatabaseRequest) (info *types.DBClusterInfo, created bool, err error) {
+	dbConfig, ok := LookupEngine(req.Type)
 	if !ok {
-		return fmt.Errorf("unsupported database type: %s", req.Type)
+		return nil, false, fmt.Errorf("unsupported database type: %s", req.Type)
 	}
 
 	version := req.Version
 	if version == "" {
-		if defaultVer, ok := DefaultVersions[req.Type]; ok {
-			version = defaultVer
-		} else {
-			return fmt.Errorf("version not provided and no default available")
+		if dbConfig.DefaultVersion == "" {
+			return nil, false, fmt.Errorf("version not provided and no default available")
 		}
+		version = dbConfig.DefaultVersion
 	}
 
-	formattedVersion := fmt.Sprintf(dbConfig.Version, version)
+	topology, err := ResolveTopology(dbConfig, req.Topology, req.Replicas, req.Shards)
+	if err != nil {
+		return nil, false, err
+	}
+	if err := ValidateScheduling(req); err != nil {
+		return nil, false, err
+	}
+	if err := ValidateVolumes(req, dbConfig); err != nil {
+		return nil, false, err
+	}
 
-	if err := c.CreateServiceAccount(ctx, req.Name, req.Namespace); err != nil {
-		return fmt.Errorf("failed to create ServiceAccount: %w", err)
+	terminationPolicy := req.TerminationPolicy
+	if terminationPolicy == "" {
+		terminationPolicy = DefaultTerminationPolicy
 	}
-	if err := c.CreateRole(ctx, req.Name, req.Namespace); err != nil {
-		return fmt.Errorf("failed to create Role: %w", err)
+	if !ValidTerminationPolicy(terminationPolicy) {
+		return nil, false, fmt.Errorf("unsupported termination policy: %s", terminationPolicy)
 	}
-	if err := c.CreateRoleBinding(ctx, req.Name, req.Namespace); err != nil {
-		return fmt.Errorf("failed to create RoleBinding: %w", err)
+
+	apiVersion, err := c.KubeBlocksAPIVersion()
+	if err != nil {
+		return nil, false, err
+	}
+	if apiVersion == APIVersionV1 && terminationPolicy == TerminationPolicyHalt {
+		return nil, false, fmt.Errorf("termination policy %s is not supported by %s/%s", terminationPolicy, KubeBlocksGroup, apiVersion)
+	}
+	gvr := kubeBlocksGVR(apiVersion, "clusters")
+
+	desired := types.DBClusterInfo{
+		Name:              req.Name,
+		Type:              dbConfig.Definition,
+		Version:           clusterVersionName(apiVersion, dbConfig, version),
+		CPULimit:          req.CPULimit,
+		MemoryLimit:       req.MemoryLimit,
+		CPURequest:        req.CPURequest,
+		MemoryRequest:     req.MemoryRequest,
+		Storage:           req.Storage,
+		StorageClass:      req.StorageClass,
+		TerminationPolicy: terminationPolicy,
+		Topology:          topology.Name,
+		Replicas:          int64(topology.Replicas),
+		Shards:            int64(topology.Shards),
+	}
+	existing, err := c.DynamicClient.Resource(gvr).Namespace(req.Namespace).Get(ctx, req.Name, metav1.GetOptions{})
+	if err == nil {
+		info, err := parseClusterInfo(existing)
+		if err != nil {
+			return nil, false, err
+		}
+		if diff := DiffClusterSpec(&info, &desired); len(diff) > 0 {
+			return &info, false, &SpecConflictError{Name: req.Name, Diff: diff}
+		}
+		// The cluster is already what was asked for; make sure the RBAC
+		// objects from a previous partial attempt are in place too.
+		if err := c.ensureRBAC(ctx, req.Name, req.Namespace, dbConfig.Rules, nil); err != nil {
+			return nil, false, err
+		}
+		return &info, false, nil
 	}
-	//waiting the sa create.
-	time.Sleep(1 * time.Second)
-	cluster := &unstructured.Unstructured{
+	if !apierrors.IsNotFound(err) {
+		return nil, false, fmt.Errorf("failed to get cluster: %w", err)
+	}
+	if err = c.ValidateVersion(ctx, req.Type, version); err != nil {
+		return nil, false, err
+	}
+	if req.StorageClass != "" {
+		if err = c.ValidateStorageClass(ctx, req.StorageClass); err != nil {
+			return nil, false, err
+		}
+	}
+
+	// Undo whatever was created so far if a later step fails, so a retry
+	// with the same name does not run into AlreadyExists. Objects that were
+	// adopted rather than created are left alone.
+	var rollback []func() error
+	defer func() {
+		if err == nil {
+			return
+		}
+		for i := len(rollback) - 1; i >= 0; i-- {
+			if rbErr := rollback[i](); rbErr != nil {
+				slog.ErrorContext(ctx, "Failed to roll back database cluster", "namespace", req.Namespace, "name", req.Name, "error", rbErr)
+			}
+		}
+	}()
+	if err = c.ensureRBAC(ctx, req.Name, req.Namespace, dbConfig.Rules, &rollback); err != nil {
+		return nil, false, err
+	}
+	if err = c.WaitForRBAC(ctx, req.Name, req.Namespace, RBACReadyTimeout); err != nil {
+		return nil, false, err
+	}
+	cluster := buildCluster(req, dbConfig, topology, apiVersion, version, terminationPolicy)
+	result, err := c.DynamicClient.Resource(gvr).Namespace(req.Namespace).Create(ctx, cluster, metav1.CreateOptions{})
+	if err != nil {
+		return nil, false, err
+	}
+	clusterInfo, err := parseClusterInfo(result)
+	if err != nil {
+		return nil, false, err
+	}
+	return &clusterInfo, true, nil
+}
+
+// clusterVersionName returns the version reported for a cluster: the
+// ClusterVersion name for v1alpha1 and the serviceVersion for v1.
+func clusterVersionName(apiVersion string, dbConfig DatabaseConfig, version string) string {
+	if apiVersion == APIVersionV1 {
+		return version
+	}
+	return fmt.Sprintf(dbConfig.Version, version)
+}
+
+// buildCluster renders the Cluster CR for req in the given KubeBlocks API
+// version, with one componentSpec per component of the topology and a
+// shardingSpec when the topology is sharded.
+func buildCluster(req *types.CreateDatabaseRequest, dbConfig DatabaseConfig, topology *Topology, apiVersion, version, terminationPolicy string) *unstructured.Unstructured {
+	componentSpecs := make([]interface{}, 0, len(topology.Components))
+	for i, component := range topology.Components {
+		main := i == 0 && topology.Sharding == nil
+		spec := buildComponentSpec(req, component, topology.componentReplicas(i, component), apiVersion, main)
+		if main {
+			if apiVersion == APIVersionV1 {
+				// Only the main component follows the requested version;
+				// auxiliary components use their own default.
+				spec["serviceVersion"] = version
+			} else {
+				spec["switchPolicy"] = map[string]interface{}{
+					"type": topology.SwitchPolicy,
+				}
+			}
+		}
+		componentSpecs = append(componentSpecs, spec)
+	}
+
+	labels := map[string]interface{}{
+		"clusterdefinition.kubeblocks.io/name": dbConfig.Definition,
+		ProviderLabel:                          req.Name,
+	}
+	labels[ProviderTopologyLabel] = topology.Name
+	spec := map[string]interface{}{
+		"componentSpecs":    componentSpecs,
+		"terminationPolicy": terminationPolicy,
+	}
+	if topology.Sharding != nil {
+		template := buildComponentSpec(req, ComponentConfig{Name: topology.Sharding.Name, DefRef: topology.Sharding.DefRef}, topology.Replicas, apiVersion, true)
+		// Sharding templates always reference a ComponentDefinition.
+		delete(template, "componentDefRef")
+		template["componentDef"] = topology.Sharding.DefRef
+		if apiVersion == APIVersionV1 {
+			template["serviceVersion"] = version
+		}
+		spec["shardingSpecs"] = []interface{}{
+			map[string]interface{}{
+				"name":     topology.Sharding.Name,
+				"shards":   int64(topology.Shards),
+				"template": template,
+			},
+		}
+	}
+	if apiVersion == APIVersionV1 {
+		spec["clusterDef"] = dbConfig.Definition
+		if topology.ClusterTopology != "" {
+			spec["topology"] = topology.ClusterTopology
+		}
+		spec["schedulingPolicy"] = renderSchedulingPolicyV1(req)
+	} else {
+		formattedVersion := fmt.Sprintf(dbConfig.Version, version)
+		labels["clusterversion.kubeblocks.io/name"] = formattedVersion
+		spec["clusterDefinitionRef"] = dbConfig.Definition
+		spec["clusterVersionRef"] = formattedVersion
+		spec["affinity"] = renderAffinityV1Alpha1(req)
+		spec["tolerations"] = renderTolerations(req.Tolerations)
+	}
+	return &unstructured.Unstructured{
 		Object: map[string]interface{}{
-			"apiVersion": "apps.kubeblocks.io/v1alpha1",
+			"apiVersion": KubeBlocksGroup + "/" + apiVersion,
 			"kind":       "Cluster",
 			"metadata": map[string]interface{}{
 				"name":      req.Name,
 				"namespace": req.Namespace,
-				"finalizers": []string{
+				"finalizers": []interface{}{
 					"cluster.kubeblocks.io/finalizer",
 				},
-				"labels": map[string]interface{}{
-					"clusterdefinition.kubeblocks.io/name": dbConfig.Definition,
-					"clusterversion.kubeblocks.io/name":    formattedVersion,
-					"sealos-db-provider-cr":                req.Name,
-				},
+				"labels": labels,
 			},
-			"spec": map[string]interface{}{
-				"affinity": map[string]interface{}{
-					"nodeLabels":      map[string]interface{}{},
-					"podAntiAffinity": "Preferred",
-					"tenancy":         "SharedNode",
-					"topologyKeys": []string{
-						"kubernetes.io/hostname",
-					},
-				},
-				"clusterDefinitionRef": dbConfig.Definition,
-				"clusterVersionRef":    formattedVersion,
-				"componentSpecs": []map[string]interface{}{
-					{
-						"componentDefRef": dbConfig.Component,
-						"monitor":         true,
-						"name":            dbConfig.Component,
-						"replicas":        1,
-						"resources": map[string]interface{}{
-							"limits": map[string]interface{}{
-								"cpu":    req.CPULimit,
-								"memory": req.MemoryLimit,
-							},
-							"requests": map[string]interface{}{
-								"cpu":    req.CPURequest,
-								"memory": req.MemoryRequest,
-							},
-						},
-						"serviceAccountName": req.Name,
-						"switchPolicy": map[string]interface{}{
-							"type": "Noop",
-						},
-						"volumeClaimTemplates": []map[string]interface{}{
-							{
-								"name": "data",
-								"spec": map[string]interface{}{
-									"accessModes": []string{
-										"ReadWriteOnce",
-									},
-									"resources": map[string]interface{}{
-										"requests": map[string]interface{}{
-											"storage": req.Storage,
-										},
-									},
-								},
-							},
-						},
-					},
-				},
-				"terminationPolicy": "Delete",
-				"tolerations":       []interface{}{},
+			"spec": spec,
+		},
+	}
+}
+
+// buildComponentSpec renders one entry of componentSpecs. Only the main
+// component gets the extra volumes of req.
+func buildComponentSpec(req *types.CreateDatabaseRequest, component ComponentConfig, replicas int, apiVersion string, main bool) map[string]interface{} {
+	spec := map[string]interface{}{
+		"name":     component.Name,
+		"replicas": int64(replicas),
+		"resources": map[string]interface{}{
+			"limits": map[string]interface{}{
+				"cpu":    req.CPULimit,
+				"memory": req.MemoryLimit,
+			},
+			"requests": map[string]interface{}{
+				"cpu":    req.CPURequest,
+				"memory": req.MemoryRequest,
 			},
 		},
+		"serviceAccountName":   req.Name,
+		"volumeClaimTemplates": buildVolumeClaimTemplates(req, main),
 	}
-	_, err := c.DynamicClient.Resource(DatabaseClusterGVR).Namespace(req.Namespace).Create(ctx, cluster, metav1.CreateOptions{})
-	return err
+	if apiVersion == APIVersionV1 {
+		spec["componentDef"] = component.DefRef
+	} else {
+		spec["componentDefRef"] = component.DefRef
+		spec["monitor"] = true
+		spec["switchPolicy"] = map[string]interface{}{
+			"type": DefaultSwitchPolicy,
+		}
+	}
+	return spec
 }
 
-func (c *Client) ListDatabaseClusters(namespace string) ([]types.DBClusterInfo, error) {
-	clusters, err := c.DynamicClient.Resource(DatabaseClusterGVR).Namespace(namespace).List(context.TODO(), metav1.ListOptions{})
+// ensureRBAC creates or adopts the ServiceAccount, Role and RoleBinding of a
+// cluster. Nothing is changed if any of them exists without being labelled
+// for the cluster. When rollback is non-nil, an undo step is appended for
+// every object that was newly created.
+func (c *Client) ensureRBAC(ctx context.Context, name, namespace string, rules []rbacv1.PolicyRule, rollback *[]func() error) error {
+	if err := c.CheckRBACOwnership(ctx, name, namespace); err != nil {
+		return err
+	}
+	cleanup := context.WithoutCancel(ctx)
+	track := func(created bool, undo func(context.Context, string, string) error) {
+		if created && rollback != nil {
+			*rollback = append(*rollback, func() error { return undo(cleanup, name, namespace) })
+		}
+	}
+	created, err := c.EnsureServiceAccount(ctx, name, namespace)
+	if err != nil {
+		return fmt.Errorf("failed to create ServiceAccount: %w", err)
+	}
+	track(created, c.DeleteServiceAccount)
+	created, err = c.EnsureRole(ctx, name, namespace, rules)
+	if err != nil {
+		return fmt.Errorf("failed to create Role: %w", err)
+	}
+	track(created, c.DeleteRole)
+	created, err = c.EnsureRoleBinding(ctx, name, namespace)
+	if err != nil {
+		return fmt.Errorf("failed to create RoleBinding: %w", err)
+	}
+	track(created, c.DeleteRoleBinding)
+	return nil
+}
+
+// SpecConflictError is returned when a cluster with the requested name
+// already exists with a different spec.
+type SpecConflictError struct {
+	Name string
+	Diff []types.FieldDiff
+}
+
+func (e *SpecConflictError) Error() string {
+	fields := make([]string, 0, len(e.Diff))
+	for _, d := range e.Diff {
+		fields = append(fields, d.Field)
+	}
+	return fmt.Sprintf("cluster %s already exists with a different spec (%s)", e.Name, strings.Join(fields, ", "))
+}
+
+// DiffClusterSpec compares the user-settable fields of two clusters and
+// returns the ones that differ. Resource quantities are compared by value,
+// so "1" and "1000m" are equal.
+func DiffClusterSpec(existing, requested *types.DBClusterInfo) []types.FieldDiff {
+	var diff []types.FieldDiff
+	compare := func(field, have, want string, quantity bool) {
+		if have == want {
+			return
+		}
+		if quantity {
+			h, hErr := resource.ParseQuantity(have)
+			w, wErr := resource.ParseQuantity(want)
+			if hErr == nil && wErr == nil && h.Cmp(w) == 0 {
+				return
+			}
+		}
+		diff = append(diff, types.FieldDiff{Field: field, Existing: have, Requested: want})
+	}
+	compare("type", existing.Type, requested.Type, false)
+	compare("version", existing.Version, requested.Version, false)
+	compare("cpu", existing.CPULimit, requested.CPULimit, true)
+	compare("memory", existing.MemoryLimit, requested.MemoryLimit, true)
+	compare("cpu_request", existing.CPURequest, requested.CPURequest, true)
+	compare("memory_request", existing.MemoryRequest, requested.MemoryRequest, true)
+	compare("storage", existing.Storage, requested.Storage, true)
+	compare("storage_class", existing.StorageClass, requested.StorageClass, false)
+	compare("termination_policy", existing.TerminationPolicy, requested.TerminationPolicy, false)
+	compare("topology", existing.Topology, requested.Topology, false)
+	compare("replicas", strconv.FormatInt(existing.Replicas, 10), strconv.FormatInt(requested.Replicas, 10), false)
+	compare("shards", strconv.FormatInt(existing.Shards, 10), strconv.FormatInt(requested.Shards, 10), false)
+	return diff
+}
+
+func (c *Client) ListDatabaseClusters(ctx context.Context, namespace string) ([]types.DBClusterInfo, error) {
+	gvr, err := c.clusterGVR()
+	if err != nil {
+		return nil, err
+	}
+	clusters, err := c.DynamicClient.Resource(gvr).Namespace(namespace).List(ctx, metav1.ListOptions{})
 	if err != nil {
 		return nil, err
 	}
+	// Pod roles and volumes are informational; a caller that may list
+	// clusters but not pods or claims still gets the clusters.
+	pods, err := c.listPodRoles(ctx, namespace, "")
+	if err != nil {
+		slog.WarnContext(ctx, "Failed to list database pods, omitting pod roles", "namespace", namespace, "error", err)
+	}
+	volumes, err := c.listVolumes(ctx, namespace, "")
+	if err != nil {
+		slog.WarnContext(ctx, "Failed to list database volumes, omitting volumes", "namespace", namespace, "error", err)
+	}
 	result := make([]types.DBClusterInfo, 0)
 	for _, cluster := range clusters.Items {
-		metadata, found, err := unstructured.NestedMap(cluster.Object, "metadata")
-		if err != nil || !found {
-			log.Printf("Failed to get metadata for cluster: %v", err)
+		clusterInfo, err := parseClusterInfo(&cluster)
+		if err != nil {
+			slog.Warn("Skipping unparsable database cluster", "namespace", namespace, "name", cluster.GetName(), "error", err)
 			continue
 		}
-		name, _ := metadata["name"].(string)
-		creationTimestamp, _ := metadata["creationTimestamp"].(string)
-		labels, found, _ := unstructured.NestedMap(metadata, "labels")
-		if !found {
-			labels = map[string]interface{}{}
+		clusterInfo.Pods = pods[clusterInfo.Name]
+		clusterInfo.Volumes = volumes[clusterInfo.Name]
+		result = append(result, clusterInfo)
+	}
+	return result, nil
+}
+
+// GetDatabaseCluster returns a single cluster with the roles of its pods and
+// its bound volumes. Like in ListDatabaseClusters, pods and volumes that
+// cannot be listed are left out.
+func (c *Client) GetDatabaseCluster(ctx context.Context, name, namespace string) (*types.DBClusterInfo, error) {
+	gvr, err := c.clusterGVR()
+	if err != nil {
+		return nil, err
+	}
+	cluster, err := c.DynamicClient.Resource(gvr).Namespace(namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+	clusterInfo, err := parseClusterInfo(cluster)
+	if err != nil {
+		return nil, err
+	}
+	pods, err := c.listPodRoles(ctx, namespace, name)
+	if err != nil {
+		slog.WarnContext(ctx, "Failed to list database pods, omitting pod roles", "namespace", namespace, "name", name, "error", err)
+	}
+	clusterInfo.Pods = pods[name]
+	volumes, err := c.listVolumes(ctx, namespace, name)
+	if err != nil {
+		slog.WarnContext(ctx, "Failed to list database volumes, omitting volumes", "namespace", namespace, "name", name, "error", err)
+	}
+	clusterInfo.Volumes = volumes[name]
+	return &clusterInfo, nil
+}
+
+// listPodRoles returns the KubeBlocks pods in namespace grouped by cluster
+// name, optionally limited to one cluster. The role is the one KubeBlocks
+// assigns through the kubeblocks.io/role label, such as primary or
+// secondary.
+func (c *Client) listPodRoles(ctx context.Context, namespace, cluster string) (map[string][]types.PodInfo, error) {
+	selector := "app.kubernetes.io/managed-by=kubeblocks"
+	if cluster != "" {
+		selector += ",app.kubernetes.io/instance=" + cluster
+	}
+	pods, err := c.ClientSet.CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{LabelSelector: selector})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pods: %w", err)
+	}
+	result := map[string][]types.PodInfo{}
+	for _, pod := range pods.Items {
+		instance := pod.Labels["app.kubernetes.io/instance"]
+		result[instance] = append(result[instance], types.PodInfo{
+			Name:      pod.Name,
+			Component: pod.Labels["apps.kubeblocks.io/component-name"],
+			Role:      pod.Labels["kubeblocks.io/role"],
+			Status:    string(pod.Status.Phase),
+		})
+	}
+	return result, nil
+}
+
+// parseClusterInfo extracts the fields reported by the API from a v1alpha1
+// or v1 Cluster CR.
+func parseClusterInfo(cluster *unstructured.Unstructured) (types.DBClusterInfo, error) {
+	metadata, found, err := unstructured.NestedMap(cluster.Object, "metadata")
+	if err != nil || !found {
+		return types.DBClusterInfo{}, fmt.Errorf("failed to get metadata for cluster: %v", err)
+	}
+	name, _ := metadata["name"].(string)
+	creationTimestamp, _ := metadata["creationTimestamp"].(string)
+	labels, found, _ := unstructured.NestedMap(metadata, "labels")
+	if !found {
+		labels = map[string]interface{}{}
+	}
+	apiVersion := strings.TrimPrefix(cluster.GetAPIVersion(), KubeBlocksGroup+"/")
+	definitionType, _ := labels["clusterdefinition.kubeblocks.io/name"].(string)
+	versionString, _ := labels["clusterversion.kubeblocks.io/name"].(string)
+	status := "Unknown"
+	statusObj, found, _ := unstructured.NestedMap(cluster.Object, "status")
+	if found {
+		if phase, ok := statusObj["phase"].(string); ok {
+			status = phase
 		}
-		definitionType, _ := labels["clusterdefinition.kubeblocks.io/name"].(string)
-		versionString, _ := labels["clusterversion.kubeblocks.io/name"].(string)
-		status := "Unknown"
-		statusObj, found, _ := unstructured.NestedMap(cluster.Object, "status")
-		if found {
-			if phase, ok := statusObj["phase"].(string); ok {
-				status = phase
+	}
+	spec, found, _ := unstructured.NestedMap(cluster.Object, "spec")
+	if !found {
+		spec = map[string]interface{}{}
+	}
+	terminationPolicy, _ := spec["terminationPolicy"].(string)
+	if clusterDef, ok := spec["clusterDef"].(string); ok && clusterDef != "" {
+		definitionType = clusterDef
+	}
+	componentSpecsUntyped, found, _ := unstructured.NestedSlice(spec, "componentSpecs")
+	// For sharded clusters the shard template is the data component.
+	var shards int64
+	shardingSpecs, shardingFound, _ := unstructured.NestedSlice(spec, "shardingSpecs")
+	if shardingFound && len(shardingSpecs) > 0 {
+		if sharding, ok := shardingSpecs[0].(map[string]interface{}); ok {
+			shards, _ = sharding["shards"].(int64)
+			if template, ok := sharding["template"].(map[string]interface{}); ok {
+				componentSpecsUntyped = append([]interface{}{template}, componentSpecsUntyped...)
+				found = true
 			}
 		}
-		spec, found, _ := unstructured.NestedMap(cluster.Object, "spec")
-		if !found {
-			spec = map[string]interface{}{}
-		}
-		componentSpecsUntyped, found, _ := unstructured.NestedSlice(spec, "componentSpecs")
-		cpuLimit := ""
-		memLimit := ""
-		cpuRequest := ""
-		memRequest := ""
-		storage := ""
-		accessMode := ""
-		var replicas int64 = 0
-		serviceAccount := ""
-		if found && len(componentSpecsUntyped) > 0 {
-			mainComponent, ok := componentSpecsUntyped[0].(map[string]interface{})
-			if ok {
-				resources, found, _ := unstructured.NestedMap(mainComponent, "resources")
-				if found {
-					limits, limitsFound, _ := unstructured.NestedMap(resources, "limits")
-					if limitsFound {
-						if cpu, ok := limits["cpu"].(string); ok {
-							cpuLimit = cpu
-						}
-						if mem, ok := limits["memory"].(string); ok {
-							memLimit = mem
-						}
+	}
+	cpuLimit := ""
+	memLimit := ""
+	cpuRequest := ""
+	memRequest := ""
+	storage := ""
+	storageClass := ""
+	accessMode := ""
+	var replicas int64 = 0
+	serviceAccount := ""
+	if found && len(componentSpecsUntyped) > 0 {
+		mainComponent, ok := componentSpecsUntyped[0].(map[string]interface{})
+		if ok {
+			resources, found, _ := unstructured.NestedMap(mainComponent, "resources")
+			if found {
+				limits, limitsFound, _ := unstructured.NestedMap(resources, "limits")
+				if limitsFound {
+					if cpu, ok := limits["cpu"].(string); ok {
+						cpuLimit = cpu
 					}
-
-					requests, reqFound, _ := unstructured.NestedMap(resources, "requests")
-					if reqFound {
-						if cpu, ok := requests["cpu"].(string); ok {
-							cpuRequest = cpu
-						}
-						if mem, ok := requests["memory"].(string); ok {
-							memRequest = mem
-						}
+					if mem, ok := limits["memory"].(string); ok {
+						memLimit = mem
 					}
 				}
 
-				if rep, ok := mainComponent["replicas"].(int64); ok {
-					replicas = rep
+				requests, reqFound, _ := unstructured.NestedMap(resources, "requests")
+				if reqFound {
+					if cpu, ok := requests["cpu"].(string); ok {
+						cpuRequest = cpu
+					}
+					if mem, ok := requests["memory"].(string); ok {
+						memRequest = mem
+					}
 				}
-				if sa, ok := mainComponent["serviceAccountName"].(string); ok {
-					serviceAccount = sa
+			}
+
+			if apiVersion == APIVersionV1 {
+				if v, ok := mainComponent["serviceVersion"].(string); ok {
+					versionString = v
 				}
-				volumeTemplates, found, _ := unstructured.NestedSlice(mainComponent, "volumeClaimTemplates")
-				if found && len(volumeTemplates) > 0 {
-					for _, volUntyped := range volumeTemplates {
-						vol, ok := volUntyped.(map[string]interface{})
-						if !ok {
-							continue
-						}
-						volName, _ := vol["name"].(string)
-						if volName == "data" {
-							spec, specFound, _ := unstructured.NestedMap(vol, "spec")
-							if specFound {
-								resourcesMap, resFound, _ := unstructured.NestedMap(spec, "resources")
-								if resFound {
-									requestsMap, reqFound, _ := unstructured.NestedMap(resourcesMap, "requests")
-									if reqFound {
-										if st, ok := requestsMap["storage"].(string); ok {
-											storage = st
-										}
+			}
+			if rep, ok := mainComponent["replicas"].(int64); ok {
+				replicas = rep
+			}
+			if sa, ok := mainComponent["serviceAccountName"].(string); ok {
+				serviceAccount = sa
+			}
+			volumeTemplates, found, _ := unstructured.NestedSlice(mainComponent, "volumeClaimTemplates")
+			if found && len(volumeTemplates) > 0 {
+				for _, volUntyped := range volumeTemplates {
+					vol, ok := volUntyped.(map[string]interface{})
+					if !ok {
+						continue
+					}
+					volName, _ := vol["name"].(string)
+					if volName == DataVolume {
+						spec, specFound, _ := unstructured.NestedMap(vol, "spec")
+						if specFound {
+							resourcesMap, resFound, _ := unstructured.NestedMap(spec, "resources")
+							if resFound {
+								requestsMap, reqFound, _ := unstructured.NestedMap(resourcesMap, "requests")
+								if reqFound {
+									if st, ok := requestsMap["storage"].(string); ok {
+										storage = st
 									}
 								}
-								accessModes, modesFound, _ := unstructured.NestedStringSlice(spec, "accessModes")
-								if modesFound && len(accessModes) > 0 {
-									accessMode = accessModes[0]
-								}
 							}
-							break
+							storageClass, _ = spec["storageClassName"].(string)
+							accessModes, modesFound, _ := unstructured.NestedStringSlice(spec, "accessModes")
+							if modesFound && len(accessModes) > 0 {
+								accessMode = accessModes[0]
+							}
 						}
+						break
 					}
 				}
 			}
 		}
-		clusterInfo := types.DBClusterInfo{
-			Name:           name,
-			Type:           definitionType,
-			Version:        versionString,
-			Status:         status,
-			CreatedAt:      creationTimestamp,
-			CPULimit:       cpuLimit,
-			MemoryLimit:    memLimit,
-			CPURequest:     cpuRequest,
-			MemoryRequest:  memRequest,
-			Storage:        storage,
-			AccessMode:     accessMode,
-			Replicas:       replicas,
-			ServiceAccount: serviceAccount,
-		}
+	}
+	clusterInfo := types.DBClusterInfo{
+		Name:              name,
+		Type:              definitionType,
+		Version:           versionString,
+		Status:            status,
+		CreatedAt:         creationTimestamp,
+		CPULimit:          cpuLimit,
+		MemoryLimit:       memLimit,
+		CPURequest:        cpuRequest,
+		MemoryRequest:     memRequest,
+		Storage:           storage,
+		StorageClass:      storageClass,
+		AccessMode:        accessMode,
+		Replicas:          replicas,
+		ServiceAccount:    serviceAccount,
+		TerminationPolicy: terminationPolicy,
+		APIVersion:        apiVersion,
+		Shards:            shards,
+	}
+	clusterInfo.Topology, _ = labels[ProviderTopologyLabel].(string)
+	if clusterInfo.Topology == "" && shards == 0 && replicas <= 1 {
+		clusterInfo.Topology = TopologyStandalone
+	}
+	return clusterInfo, nil
+}
 
-		fmt.Println(clusterInfo)
-		result = append(result, clusterInfo)
+// GetTerminationPolicy returns the terminationPolicy of the named cluster.
+func (c *Client) GetTerminationPolicy(ctx context.Context, name, namespace string) (string, error) {
+	gvr, err := c.clusterGVR()
+	if err != nil {
+		return "", err
 	}
-	return result, nil
+	cluster, err := c.DynamicClient.Resource(gvr).Namespace(namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return "", err
+	}
+	policy, _, err := unstructured.NestedString(cluster.Object, "spec", "terminationPolicy")
+	if err != nil {
+		return "", err
+	}
+	return policy, nil
 }
 
+// UpdateTerminationPolicy patches the terminationPolicy of an existing cluster.
+func (c *Client) UpdateTerminationPolicy(ctx context.Context, name, namespace, policy string) error {
+	if !ValidTerminationPolicy(policy) {
+		return fmt.Errorf("unsupported termination policy: %s", policy)
+	}
+	gvr, err := c.clusterGVR()
+	if err != nil {
+		return err
+	}
+	if gvr.Version == APIVersionV1 && policy == TerminationPolicyHalt {
+		return fmt.Errorf("termination policy %s is not supported by %s/%s", policy, KubeBlocksGroup, gvr.Version)
+	}
+	patch, err := json.Marshal(map[string]interface{}{
+		"spec": map[string]interface{}{
+			"terminationPolicy": policy,
+		},
+	})
+	if err != nil {
+		return err
+	}
+	_, err = c.DynamicClient.
+		Resource(gvr).
+		Namespace(namespace).
+		Patch(ctx, name, k8stypes.MergePatchType, patch, metav1.PatchOptions{})
+	return err
+}
+
+// DeleteDatabaseCluster deletes the Cluster CR together with the
+// ServiceAccount, Role and RoleBinding created for it.
 func (c *Client) DeleteDatabaseCluster(ctx context.Context, name, namespace string) error {
-	return c.DynamicClient.
-		Resource(DatabaseClusterGVR).
+	gvr, err := c.clusterGVR()
+	if err != nil {
+		return err
+	}
+	err = c.DynamicClient.
+		Resource(gvr).
 		Namespace(namespace).
 		Delete(ctx, name, metav1.DeleteOptions{})
+	if err != nil && !apierrors.IsNotFound(err) {
+		return err
+	}
+	return c.DeleteRBAC(ctx, name, namespace)
 }
@@ -0,0 +1,36 @@
+package k8s
+
+import (
+	"context"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	k8stesting "k8s.io/client-go/testing"
+	"testing"
+)
+
+// Pods and claims the caller may not list are left out of the cluster
+// info rather than failing the request.
+func TestClusterInfoWithoutPodAndClaimAccess(t *testing.T) {
+	c, clientSet := newKubeBlocksClient(t, APIVersionV1)
+	for _, resource := range []string{"pods", "persistentvolumeclaims"} {
+		clientSet.PrependReactor("list", resource, func(action k8stesting.Action) (bool, runtime.Object, error) {
+			return true, nil, apierrors.NewForbidden(schema.GroupResource{Resource: action.GetResource().Resource}, "", nil)
+		})
+	}
+	ctx := context.Background()
+	clusters, err := c.ListDatabaseClusters(ctx, "tenant")
+	if err != nil {
+		t.Fatalf("ListDatabaseClusters() = %v", err)
+	}
+	if len(clusters) != 1 || clusters[0].Name != "render" || clusters[0].Volumes != nil || clusters[0].Pods != nil {
+		t.Errorf("ListDatabaseClusters() = %+v, want render without pods and volumes", clusters)
+	}
+	cluster, err := c.GetDatabaseCluster(ctx, "render", "tenant")
+	if err != nil {
+		t.Fatalf("GetDatabaseCluster() = %v", err)
+	}
+	if cluster.Name != "render" || cluster.Volumes != nil || cluster.Pods != nil {
+		t.Errorf("GetDatabaseCluster() = %+v, want render without pods and volumes", cluster)
+	}
+}
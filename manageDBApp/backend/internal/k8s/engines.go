@@ -0,0 +1,451 @@
+package k8s
+
+import (
+	"fmt"
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"mcp-db/pkg/types"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// DatabaseConfig describes how a database type maps onto KubeBlocks objects.
+// The JSON tags define the schema of the engine file.
+type DatabaseConfig struct {
+	Definition string `json:"definition"`
+	// Version is a fmt template turning a version into a ClusterVersion
+	// name, such as "postgresql-%s".
+	Version         string            `json:"versionTemplate"`
+	DefaultVersion  string            `json:"defaultVersion"`
+	AllowedVersions []string          `json:"allowedVersions,omitempty"`
+	Components      []ComponentConfig `json:"components"`
+	Resources       *ResourceDefaults `json:"resources,omitempty"`
+	// Volumes lists the extra volumeClaimTemplates, besides data, that the
+	// main component supports, such as a separate log volume.
+	Volumes []string `json:"volumes,omitempty"`
+	// Topologies are the deployment shapes beyond standalone, selected by
+	// the topology field of a create request.
+	Topologies      map[string]TopologyConfig `json:"topologies,omitempty"`
+	DefaultTopology string                    `json:"defaultTopology,omitempty"`
+	// Rules are granted to the ServiceAccount of the database pods.
+	Rules []rbacv1.PolicyRule `json:"rules,omitempty"`
+}
+
+// ComponentConfig is one entry of the Cluster componentSpecs. The first
+// component of a DatabaseConfig is the one reported by the list API.
+type ComponentConfig struct {
+	Name     string `json:"name"`
+	DefRef   string `json:"componentDefRef"`
+	Replicas int    `json:"replicas,omitempty"`
+}
+
+// ResourceDefaults override the service-wide CPU, memory and storage
+// defaults for one engine.
+type ResourceDefaults struct {
+	CPU     string `json:"cpu,omitempty"`
+	Memory  string `json:"memory,omitempty"`
+	Storage string `json:"storage,omitempty"`
+}
+
+// AllowsVersion reports whether version may be requested. An empty
+// AllowedVersions list allows any version.
+func (d DatabaseConfig) AllowsVersion(version string) bool {
+	if len(d.AllowedVersions) == 0 {
+		return true
+	}
+	for _, v := range d.AllowedVersions {
+		if v == version {
+			return true
+		}
+	}
+	return false
+}
+
+var (
+	enginesMu sync.RWMutex
+	// engines is the active registry. It starts as DatabaseConfigs and is
+	// swapped as a whole by SetEngines.
+	engines = DatabaseConfigs
+	// roleRuleOverrides are applied on top of every registry swap.
+	roleRuleOverrides map[string][]rbacv1.PolicyRule
+)
+
+// LookupEngine returns the active config for a database type.
+func LookupEngine(dbType string) (DatabaseConfig, bool) {
+	enginesMu.RLock()
+	defer enginesMu.RUnlock()
+	dbConfig, ok := engines[dbType]
+	return dbConfig, ok
+}
+
+// EngineForDefinition returns the registry type whose ClusterDefinition is
+// definition.
+func EngineForDefinition(definition string) (string, bool) {
+	for dbType, dbConfig := range Engines() {
+		if dbConfig.Definition == definition {
+			return dbType, true
+		}
+	}
+	return "", false
+}
+
+// Engines returns a copy of the active registry.
+func Engines() map[string]DatabaseConfig {
+	enginesMu.RLock()
+	defer enginesMu.RUnlock()
+	result := make(map[string]DatabaseConfig, len(engines))
+	for dbType, dbConfig := range engines {
+		result[dbType] = dbConfig
+	}
+	return result
+}
+
+// SetEngines validates configs and makes them the active registry. On error
+// the previous registry stays in place.
+func SetEngines(configs map[string]DatabaseConfig) error {
+	enginesMu.Lock()
+	defer enginesMu.Unlock()
+	return setEnginesLocked(configs, roleRuleOverrides)
+}
+
+func setEnginesLocked(configs map[string]DatabaseConfig, overrides map[string][]rbacv1.PolicyRule) error {
+	if len(configs) == 0 {
+		return fmt.Errorf("engine registry is empty")
+	}
+	next := make(map[string]DatabaseConfig, len(configs))
+	for dbType, dbConfig := range configs {
+		if len(dbConfig.Rules) == 0 {
+			dbConfig.Rules = defaultRoleRules
+			if builtin, ok := DatabaseConfigs[dbType]; ok {
+				dbConfig.Rules = builtin.Rules
+			}
+		}
+		next[dbType] = dbConfig
+	}
+	for dbType, rules := range overrides {
+		dbConfig, ok := next[dbType]
+		if !ok {
+			return fmt.Errorf("role rules for unsupported database type: %s", dbType)
+		}
+		dbConfig.Rules = rules
+		next[dbType] = dbConfig
+	}
+	if err := validateDatabaseConfigs(next); err != nil {
+		return err
+	}
+	engines = next
+	roleRuleOverrides = overrides
+	return nil
+}
+
+// singleComponent is the common case of an engine whose component is named
+// after its componentDef.
+func singleComponent(name string) []ComponentConfig {
+	return []ComponentConfig{{Name: name, DefRef: name}}
+}
+
+// DatabaseConfigs is the built-in engine registry, used until an engine
+// file is loaded with LoadEngineFile.
+var DatabaseConfigs = map[string]DatabaseConfig{
+	"postgresql": {
+		Definition:     "postgresql",
+		Version:        "postgresql-%s",
+		DefaultVersion: "14.8.0",
+		Components:     singleComponent("postgresql"),
+		Topologies: map[string]TopologyConfig{
+			"replication": {
+				DefaultReplicas: 2,
+				MinReplicas:     2,
+				SwitchPolicy:    "MaximumAvailability",
+				ClusterTopology: "replication",
+			},
+		},
+		Rules: postgresqlRoleRules,
+	},
+	"mysql": {
+		Definition:     "apecloud-mysql",
+		Version:        "ac-mysql-%s",
+		DefaultVersion: "8.0.30",
+		Components:     singleComponent("mysql"),
+		Volumes:        []string{"log"},
+		Topologies: map[string]TopologyConfig{
+			"raft": {
+				DefaultReplicas: 3,
+				MinReplicas:     3,
+				OddReplicas:     true,
+				SwitchPolicy:    "MaximumAvailability",
+				ClusterTopology: "raftGroup",
+			},
+		},
+		Rules: defaultRoleRules,
+	},
+	"redis": {
+		Definition:     "redis",
+		Version:        "redis-%s",
+		DefaultVersion: "7.0.6",
+		Components:     singleComponent("redis"),
+		Topologies: map[string]TopologyConfig{
+			"sentinel": {
+				Components: []ComponentConfig{
+					{Name: "redis", DefRef: "redis"},
+					{Name: "redis-sentinel", DefRef: "redis-sentinel", Replicas: 3},
+				},
+				DefaultReplicas: 2,
+				MinReplicas:     2,
+				ClusterTopology: "replication",
+			},
+			"cluster": {
+				Components:      []ComponentConfig{},
+				DefaultReplicas: 2,
+				MinReplicas:     1,
+				Sharding: &ShardingConfig{
+					Name:          "shard",
+					DefRef:        "redis-cluster",
+					DefaultShards: 3,
+					MinShards:     3,
+				},
+			},
+		},
+		Rules: defaultRoleRules,
+	},
+	"mongodb": {
+		Definition:     "mongodb",
+		Version:        "mongodb-%s",
+		DefaultVersion: "6.0",
+		Components:     singleComponent("mongodb"),
+		Topologies: map[string]TopologyConfig{
+			"replicaset": {
+				DefaultReplicas: 3,
+				MinReplicas:     3,
+				OddReplicas:     true,
+				ClusterTopology: "replicaset",
+			},
+			"sharding": {
+				Components: []ComponentConfig{
+					{Name: "mongo-config-server", DefRef: "mongo-config-server", Replicas: 3},
+					{Name: "mongo-mongos", DefRef: "mongo-mongos", Replicas: 2},
+				},
+				DefaultReplicas: 3,
+				MinReplicas:     3,
+				OddReplicas:     true,
+				Sharding: &ShardingConfig{
+					Name:          "shard",
+					DefRef:        "mongo-shard",
+					DefaultShards: 2,
+					MinShards:     1,
+				},
+			},
+		},
+		Rules: defaultRoleRules,
+	},
+	"kafka": {
+		Definition:     "kafka",
+		Version:        "kafka-%s",
+		DefaultVersion: "3.3.2",
+		Components: []ComponentConfig{
+			{Name: "kafka-broker", DefRef: "kafka-broker"},
+			{Name: "controller", DefRef: "controller"},
+		},
+		Volumes: []string{"metadata"},
+		Rules:   defaultRoleRules,
+	},
+	"milvus": {
+		Definition:     "milvus",
+		Version:        "milvus-%s",
+		DefaultVersion: "2.2.4",
+		Components: []ComponentConfig{
+			{Name: "milvus", DefRef: "milvus"},
+			{Name: "etcd", DefRef: "etcd"},
+			{Name: "minio", DefRef: "minio"},
+		},
+		Rules: defaultRoleRules,
+	},
+	"clickhouse": {
+		Definition:     "clickhouse",
+		Version:        "clickhouse-%s",
+		DefaultVersion: "22.9.4",
+		Components: []ComponentConfig{
+			{Name: "clickhouse", DefRef: "clickhouse"},
+			{Name: "ch-keeper", DefRef: "ch-keeper"},
+		},
+		Rules: defaultRoleRules,
+	},
+	"qdrant": {
+		Definition:     "qdrant",
+		Version:        "qdrant-%s",
+		DefaultVersion: "1.5.0",
+		Components:     singleComponent("qdrant"),
+		Rules:          defaultRoleRules,
+	},
+	"etcd": {
+		Definition:     "etcd",
+		Version:        "etcd-v%s",
+		DefaultVersion: "3.5.6",
+		Components:     singleComponent("etcd"),
+		Rules:          defaultRoleRules,
+	},
+	"elasticsearch": {
+		Definition:     "elasticsearch",
+		Version:        "elasticsearch-%s",
+		DefaultVersion: "8.8.2",
+		Components:     singleComponent("elasticsearch"),
+		Rules:          defaultRoleRules,
+	},
+	"weaviate": {
+		Definition:     "weaviate",
+		Version:        "weaviate-%s",
+		DefaultVersion: "1.18.0",
+		Components:     singleComponent("weaviate"),
+		Rules:          defaultRoleRules,
+	},
+	"zookeeper": {
+		Definition:     "zookeeper",
+		Version:        "zookeeper-%s",
+		DefaultVersion: "3.7.1",
+		Components:     singleComponent("zookeeper"),
+		Rules:          defaultRoleRules,
+	},
+}
+
+// ValidateDatabaseConfigs renders a Cluster for every registered engine and
+// checks that it is well-formed, so a broken registry entry is caught at
+// startup rather than on the first create request.
+func ValidateDatabaseConfigs() error {
+	return validateDatabaseConfigs(Engines())
+}
+
+func validateDatabaseConfigs(configs map[string]DatabaseConfig) error {
+	dbTypes := make([]string, 0, len(configs))
+	for dbType := range configs {
+		dbTypes = append(dbTypes, dbType)
+	}
+	sort.Strings(dbTypes)
+	for _, dbType := range dbTypes {
+		if err := validateDatabaseConfig(dbType, configs[dbType]); err != nil {
+			return fmt.Errorf("invalid config for database type %s: %w", dbType, err)
+		}
+	}
+	return nil
+}
+
+func validateDatabaseConfig(dbType string, dbConfig DatabaseConfig) error {
+	if dbConfig.Definition == "" {
+		return fmt.Errorf("definition is empty")
+	}
+	if len(dbConfig.Components) == 0 {
+		return fmt.Errorf("no components")
+	}
+	if len(dbConfig.Rules) == 0 {
+		return fmt.Errorf("no role rules")
+	}
+	if !strings.Contains(dbConfig.Version, "%s") {
+		return fmt.Errorf("version template %q must contain %%s", dbConfig.Version)
+	}
+	version := dbConfig.DefaultVersion
+	if version == "" {
+		return fmt.Errorf("no default version")
+	}
+	if !dbConfig.AllowsVersion(version) {
+		return fmt.Errorf("default version %s is not in allowedVersions", version)
+	}
+	for i, rule := range dbConfig.Rules {
+		if len(rule.Verbs) == 0 || len(rule.Resources) == 0 {
+			return fmt.Errorf("role rule %d needs at least one verb and resource", i)
+		}
+	}
+	if r := dbConfig.Resources; r != nil {
+		for field, value := range map[string]string{"cpu": r.CPU, "memory": r.Memory, "storage": r.Storage} {
+			if value == "" {
+				continue
+			}
+			if _, err := resource.ParseQuantity(value); err != nil {
+				return fmt.Errorf("resources.%s: %w", field, err)
+			}
+		}
+	}
+	formattedVersion := fmt.Sprintf(dbConfig.Version, version)
+	if strings.Contains(formattedVersion, "%!") || !strings.Contains(formattedVersion, version) {
+		return fmt.Errorf("version template %q does not reference the version", dbConfig.Version)
+	}
+	req := &types.CreateDatabaseRequest{
+		Name:          "validate",
+		Namespace:     "default",
+		Type:          dbType,
+		CPULimit:      "1000m",
+		MemoryLimit:   "1024Mi",
+		CPURequest:    "100m",
+		MemoryRequest: "102Mi",
+		Storage:       "3Gi",
+	}
+	if _, ok := dbConfig.Topologies[dbConfig.DefaultTopology]; dbConfig.DefaultTopology != "" && dbConfig.DefaultTopology != TopologyStandalone && !ok {
+		return fmt.Errorf("default topology %s is not defined", dbConfig.DefaultTopology)
+	}
+	for _, name := range dbConfig.TopologyNames() {
+		topology, err := ResolveTopology(dbConfig, name, 0, 0)
+		if err != nil {
+			return fmt.Errorf("topology %s: %w", name, err)
+		}
+		if len(topology.Components) == 0 && topology.Sharding == nil {
+			return fmt.Errorf("topology %s has no components", name)
+		}
+		for _, apiVersion := range []string{APIVersionV1Alpha1, APIVersionV1} {
+			cluster := buildCluster(req, dbConfig, topology, apiVersion, version, DefaultTerminationPolicy)
+			if err := validateRenderedCluster(cluster, dbConfig, topology, apiVersion, version); err != nil {
+				return fmt.Errorf("topology %s, %s: %w", name, apiVersion, err)
+			}
+		}
+	}
+	return nil
+}
+
+// validateRenderedCluster checks the references and componentSpecs of a
+// Cluster rendered by buildCluster.
+func validateRenderedCluster(cluster *unstructured.Unstructured, dbConfig DatabaseConfig, topology *Topology, apiVersion, version string) error {
+	definitionField, componentDefField := "clusterDefinitionRef", "componentDefRef"
+	if apiVersion == APIVersionV1 {
+		definitionField, componentDefField = "clusterDef", "componentDef"
+	} else {
+		formattedVersion := fmt.Sprintf(dbConfig.Version, version)
+		if ref, _, _ := unstructured.NestedString(cluster.Object, "spec", "clusterVersionRef"); ref != formattedVersion {
+			return fmt.Errorf("clusterVersionRef is %q", ref)
+		}
+	}
+	if ref, _, _ := unstructured.NestedString(cluster.Object, "spec", definitionField); ref != dbConfig.Definition {
+		return fmt.Errorf("%s is %q", definitionField, ref)
+	}
+	specs, found, err := unstructured.NestedSlice(cluster.Object, "spec", "componentSpecs")
+	if err != nil || !found || len(specs) != len(topology.Components) {
+		return fmt.Errorf("componentSpecs not rendered: %v", err)
+	}
+	if topology.Sharding != nil {
+		shardingSpecs, found, err := unstructured.NestedSlice(cluster.Object, "spec", "shardingSpecs")
+		if err != nil || !found || len(shardingSpecs) != 1 {
+			return fmt.Errorf("shardingSpecs not rendered: %v", err)
+		}
+		if topology.Sharding.Name == "" || topology.Sharding.DefRef == "" {
+			return fmt.Errorf("sharding needs a name and componentDef")
+		}
+	}
+	names := make(map[string]bool, len(specs))
+	for i, spec := range specs {
+		component, ok := spec.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("componentSpecs[%d] is not an object", i)
+		}
+		name, _, _ := unstructured.NestedString(component, "name")
+		defRef, _, _ := unstructured.NestedString(component, componentDefField)
+		if name == "" || defRef == "" {
+			return fmt.Errorf("componentSpecs[%d] needs a name and %s", i, componentDefField)
+		}
+		if names[name] {
+			return fmt.Errorf("duplicate component name %s", name)
+		}
+		names[name] = true
+		if _, _, err := unstructured.NestedSlice(component, "volumeClaimTemplates"); err != nil {
+			return fmt.Errorf("componentSpecs[%d] volumeClaimTemplates: %w", i, err)
+		}
+	}
+	return nil
+}
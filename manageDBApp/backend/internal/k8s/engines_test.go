@@ -0,0 +1,135 @@
+package k8s
+
+import (
+	"fmt"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"mcp-db/pkg/types"
+	"sort"
+	"testing"
+)
+
+// renderRequest is the create request every engine is rendered for.
+func renderRequest(dbType string) *types.CreateDatabaseRequest {
+	return &types.CreateDatabaseRequest{
+		Name:          "render",
+		Namespace:     "tenant",
+		Type:          dbType,
+		CPULimit:      "1000m",
+		MemoryLimit:   "1024Mi",
+		CPURequest:    "100m",
+		MemoryRequest: "102Mi",
+		Storage:       "3Gi",
+	}
+}
+
+func TestBuildClusterForEveryEngine(t *testing.T) {
+	dbTypes := make([]string, 0, len(DatabaseConfigs))
+	for dbType := range DatabaseConfigs {
+		dbTypes = append(dbTypes, dbType)
+	}
+	sort.Strings(dbTypes)
+	for _, dbType := range dbTypes {
+		dbConfig := DatabaseConfigs[dbType]
+		for _, name := range dbConfig.TopologyNames() {
+			topology, err := ResolveTopology(dbConfig, name, 0, 0)
+			if err != nil {
+				t.Errorf("%s: ResolveTopology(%s) = %v", dbType, name, err)
+				continue
+			}
+			for _, apiVersion := range []string{APIVersionV1Alpha1, APIVersionV1} {
+				t.Run(fmt.Sprintf("%s/%s/%s", dbType, name, apiVersion), func(t *testing.T) {
+					req := renderRequest(dbType)
+					version := dbConfig.DefaultVersion
+					cluster := buildCluster(req, dbConfig, topology, apiVersion, version, DefaultTerminationPolicy)
+					checkCluster(t, cluster, req, dbConfig, topology, apiVersion, version)
+				})
+			}
+		}
+	}
+}
+
+func checkCluster(t *testing.T, cluster *unstructured.Unstructured, req *types.CreateDatabaseRequest, dbConfig DatabaseConfig, topology *Topology, apiVersion, version string) {
+	t.Helper()
+	// DeepCopy panics on values that are not JSON compatible.
+	func() {
+		defer func() {
+			if r := recover(); r != nil {
+				t.Fatalf("cluster is not JSON compatible: %v", r)
+			}
+		}()
+		cluster.DeepCopy()
+	}()
+	if _, err := cluster.MarshalJSON(); err != nil {
+		t.Fatalf("MarshalJSON() = %v", err)
+	}
+	if got, want := cluster.GetAPIVersion(), KubeBlocksGroup+"/"+apiVersion; got != want {
+		t.Errorf("apiVersion = %q, want %q", got, want)
+	}
+	if cluster.GetKind() != "Cluster" {
+		t.Errorf("kind = %q, want Cluster", cluster.GetKind())
+	}
+	if cluster.GetName() != req.Name || cluster.GetNamespace() != req.Namespace {
+		t.Errorf("metadata = %s/%s, want %s/%s", cluster.GetNamespace(), cluster.GetName(), req.Namespace, req.Name)
+	}
+	labels := cluster.GetLabels()
+	if labels[ProviderLabel] != req.Name {
+		t.Errorf("label %s = %q, want %q", ProviderLabel, labels[ProviderLabel], req.Name)
+	}
+	if labels[ProviderTopologyLabel] != topology.Name {
+		t.Errorf("label %s = %q, want %q", ProviderTopologyLabel, labels[ProviderTopologyLabel], topology.Name)
+	}
+	if policy, _, _ := unstructured.NestedString(cluster.Object, "spec", "terminationPolicy"); policy != DefaultTerminationPolicy {
+		t.Errorf("terminationPolicy = %q, want %q", policy, DefaultTerminationPolicy)
+	}
+	if err := validateRenderedCluster(cluster, dbConfig, topology, apiVersion, version); err != nil {
+		t.Fatal(err)
+	}
+	specs, _, _ := unstructured.NestedSlice(cluster.Object, "spec", "componentSpecs")
+	for i, spec := range specs {
+		component := spec.(map[string]interface{})
+		if sa, _, _ := unstructured.NestedString(component, "serviceAccountName"); sa != req.Name {
+			t.Errorf("componentSpecs[%d].serviceAccountName = %q, want %q", i, sa, req.Name)
+		}
+		if replicas, _, _ := unstructured.NestedInt64(component, "replicas"); replicas < 1 {
+			t.Errorf("componentSpecs[%d].replicas = %d, want at least 1", i, replicas)
+		}
+		if cpu, _, _ := unstructured.NestedString(component, "resources", "limits", "cpu"); cpu != req.CPULimit {
+			t.Errorf("componentSpecs[%d] cpu limit = %q, want %q", i, cpu, req.CPULimit)
+		}
+		if apiVersion == APIVersionV1 && i == 0 && topology.Sharding == nil {
+			if got, _, _ := unstructured.NestedString(component, "serviceVersion"); got != version {
+				t.Errorf("componentSpecs[0].serviceVersion = %q, want %q", got, version)
+			}
+		}
+	}
+}
+
+func TestValidateDatabaseConfigs(t *testing.T) {
+	if err := validateDatabaseConfigs(DatabaseConfigs); err != nil {
+		t.Fatalf("built-in engines: %v", err)
+	}
+	broken := DatabaseConfigs["mysql"]
+	broken.Version = "mysql-8.0"
+	if err := validateDatabaseConfigs(map[string]DatabaseConfig{"mysql": broken}); err == nil {
+		t.Errorf("version template %q was accepted", broken.Version)
+	}
+}
+
+// A resource granted by two rules of an engine makes the Role differ from
+// what was declared whenever the rules are edited.
+func TestRoleRulesGrantEachResourceOnce(t *testing.T) {
+	for dbType, dbConfig := range DatabaseConfigs {
+		granted := make(map[string]bool)
+		for _, rule := range dbConfig.Rules {
+			for _, group := range rule.APIGroups {
+				for _, resource := range rule.Resources {
+					key := group + "/" + resource
+					if granted[key] {
+						t.Errorf("%s: %q is granted by more than one rule", dbType, key)
+					}
+					granted[key] = true
+				}
+			}
+		}
+	}
+}
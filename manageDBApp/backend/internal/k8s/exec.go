@@ -0,0 +1,47 @@
+package k8s
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/remotecommand"
+	"strings"
+)
+
+// execInPod runs command in a container of a pod with stdin as its input and
+// returns its stdout. A non-zero exit is reported with the stderr of the
+// command. The command travels in the URL of the exec request, which the API
+// server may log, so secrets must be passed on stdin.
+func (c *Client) execInPod(ctx context.Context, namespace, pod, container string, command []string, stdin string) (string, error) {
+	if c.config == nil {
+		return "", fmt.Errorf("client has no rest config to exec into pod %s", pod)
+	}
+	req := c.ClientSet.CoreV1().RESTClient().Post().
+		Resource("pods").
+		Namespace(namespace).
+		Name(pod).
+		SubResource("exec").
+		VersionedParams(&corev1.PodExecOptions{
+			Container: container,
+			Command:   command,
+			Stdin:     stdin != "",
+			Stdout:    true,
+			Stderr:    true,
+		}, scheme.ParameterCodec)
+	executor, err := remotecommand.NewSPDYExecutor(c.config, "POST", req.URL())
+	if err != nil {
+		return "", fmt.Errorf("failed to exec into pod %s: %w", pod, err)
+	}
+	var stdout, stderr bytes.Buffer
+	opts := remotecommand.StreamOptions{Stdout: &stdout, Stderr: &stderr}
+	if stdin != "" {
+		opts.Stdin = strings.NewReader(stdin)
+	}
+	err = executor.StreamWithContext(ctx, opts)
+	if err != nil {
+		return "", fmt.Errorf("command failed in pod %s: %w: %s", pod, err, strings.TrimSpace(stderr.String()))
+	}
+	return stdout.String(), nil
+}
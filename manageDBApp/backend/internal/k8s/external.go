@@ -0,0 +1,218 @@
+package k8s
+
+import (
+	"context"
+	"fmt"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"log/slog"
+	"mcp-db/pkg/types"
+	"strconv"
+	"strings"
+)
+
+// ExternalServiceTypes are the Service types that expose a database outside
+// the cluster.
+var ExternalServiceTypes = []string{string(corev1.ServiceTypeLoadBalancer), string(corev1.ServiceTypeNodePort)}
+
+// writableRoles are the pod roles that accept writes. When the exposed
+// component has one of them, the Service only selects that pod.
+var writableRoles = []string{"primary", "leader", "master"}
+
+// ValidExternalServiceType reports whether serviceType can expose a database.
+func ValidExternalServiceType(serviceType string) bool {
+	return contains(ExternalServiceTypes, serviceType)
+}
+
+// ExternalServiceName returns the name of the Service exposing a cluster.
+func ExternalServiceName(cluster string) string {
+	return cluster + "-external"
+}
+
+// EnableExternalAccess creates, or changes the type of, a Service exposing
+// the component clients connect to. The Service is owned by the Cluster CR
+// so it is removed with the cluster.
+func (c *Client) EnableExternalAccess(ctx context.Context, name, namespace, serviceType string) (*types.ExternalAccess, error) {
+	if !ValidExternalServiceType(serviceType) {
+		return nil, &InvalidRequestError{Err: fmt.Errorf("service type must be one of %s", strings.Join(ExternalServiceTypes, ", "))}
+	}
+	cluster, err := c.getCluster(ctx, name, namespace)
+	if err != nil {
+		return nil, err
+	}
+	creds, err := c.GetConnectionCredentials(ctx, name, namespace)
+	if err != nil {
+		return nil, err
+	}
+	port, err := strconv.Atoi(creds.Port)
+	if err != nil {
+		return nil, fmt.Errorf("connection port %q of %s is not valid: %w", creds.Port, name, err)
+	}
+	component := creds.Component
+	if component == "" {
+		return nil, fmt.Errorf("connection service of %s names no component", name)
+	}
+	selector := map[string]string{
+		"app.kubernetes.io/instance":        name,
+		"apps.kubeblocks.io/component-name": component,
+	}
+	pods, err := c.listPodRoles(ctx, namespace, name)
+	if err != nil {
+		return nil, err
+	}
+	for _, pod := range pods[name] {
+		if pod.Component == component && contains(writableRoles, pod.Role) {
+			selector["kubeblocks.io/role"] = pod.Role
+			break
+		}
+	}
+
+	services := c.ClientSet.CoreV1().Services(namespace)
+	service, err := services.Get(ctx, ExternalServiceName(name), metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		service = &corev1.Service{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: ExternalServiceName(name),
+				Labels: map[string]string{
+					"app.kubernetes.io/instance": name,
+					ProviderLabel:                name,
+				},
+				OwnerReferences: []metav1.OwnerReference{{
+					APIVersion: cluster.GetAPIVersion(),
+					Kind:       cluster.GetKind(),
+					Name:       cluster.GetName(),
+					UID:        cluster.GetUID(),
+				}},
+			},
+			Spec: corev1.ServiceSpec{
+				Type:     corev1.ServiceType(serviceType),
+				Selector: selector,
+				Ports: []corev1.ServicePort{{
+					Name:       "database",
+					Protocol:   corev1.ProtocolTCP,
+					Port:       int32(port),
+					TargetPort: intstr.FromInt(port),
+				}},
+			},
+		}
+		service, err = services.Create(ctx, service, metav1.CreateOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("failed to create service %s: %w", ExternalServiceName(name), err)
+		}
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to get service %s: %w", ExternalServiceName(name), err)
+	} else if string(service.Spec.Type) != serviceType {
+		service.Spec.Type = corev1.ServiceType(serviceType)
+		service.Spec.Selector = selector
+		for i := range service.Spec.Ports {
+			service.Spec.Ports[i].NodePort = 0
+		}
+		service, err = services.Update(ctx, service, metav1.UpdateOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("failed to update service %s: %w", ExternalServiceName(name), err)
+		}
+	}
+	return c.externalAccess(ctx, service), nil
+}
+
+// DisableExternalAccess removes the Service exposing a cluster. It is not an
+// error if the cluster is not exposed.
+func (c *Client) DisableExternalAccess(ctx context.Context, name, namespace string) error {
+	err := c.ClientSet.CoreV1().Services(namespace).Delete(ctx, ExternalServiceName(name), metav1.DeleteOptions{})
+	if err != nil && !apierrors.IsNotFound(err) {
+		return fmt.Errorf("failed to delete service %s: %w", ExternalServiceName(name), err)
+	}
+	return nil
+}
+
+// GetExternalAccess returns the reachable address of a cluster, or nil if it
+// is not exposed.
+func (c *Client) GetExternalAccess(ctx context.Context, name, namespace string) (*types.ExternalAccess, error) {
+	service, err := c.ClientSet.CoreV1().Services(namespace).Get(ctx, ExternalServiceName(name), metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get service %s: %w", ExternalServiceName(name), err)
+	}
+	return c.externalAccess(ctx, service), nil
+}
+
+// externalAccess reports the host and port of an external Service. A
+// LoadBalancer stays Pending until its address is assigned; a NodePort is
+// reached through the address of a ready node, and stays Pending when the
+// caller may not list nodes.
+func (c *Client) externalAccess(ctx context.Context, service *corev1.Service) *types.ExternalAccess {
+	access := &types.ExternalAccess{
+		ServiceType: string(service.Spec.Type),
+		Service:     service.Name,
+		Status:      "Pending",
+	}
+	if len(service.Spec.Ports) == 0 {
+		return access
+	}
+	switch service.Spec.Type {
+	case corev1.ServiceTypeLoadBalancer:
+		access.Port = strconv.Itoa(int(service.Spec.Ports[0].Port))
+		for _, ingress := range service.Status.LoadBalancer.Ingress {
+			if ingress.IP != "" {
+				access.Host = ingress.IP
+			} else if ingress.Hostname != "" {
+				access.Host = ingress.Hostname
+			}
+			if access.Host != "" {
+				break
+			}
+		}
+	case corev1.ServiceTypeNodePort:
+		if service.Spec.Ports[0].NodePort != 0 {
+			access.Port = strconv.Itoa(int(service.Spec.Ports[0].NodePort))
+		}
+		host, err := c.nodeAddress(ctx)
+		if err != nil {
+			slog.WarnContext(ctx, "Failed to find a node address for a NodePort service", "namespace", service.Namespace, "service", service.Name, "error", err)
+		}
+		access.Host = host
+	}
+	if access.Host != "" && access.Port != "" {
+		access.Status = "Ready"
+	}
+	return access
+}
+
+// nodeAddress returns the external IP of a ready node, falling back to its
+// internal IP when no node has an external one.
+func (c *Client) nodeAddress(ctx context.Context) (string, error) {
+	nodes, err := c.ClientSet.CoreV1().Nodes().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return "", fmt.Errorf("failed to list nodes: %w", err)
+	}
+	internal := ""
+	for _, node := range nodes.Items {
+		if !nodeReady(node) {
+			continue
+		}
+		for _, address := range node.Status.Addresses {
+			switch address.Type {
+			case corev1.NodeExternalIP:
+				return address.Address, nil
+			case corev1.NodeInternalIP:
+				if internal == "" {
+					internal = address.Address
+				}
+			}
+		}
+	}
+	return internal, nil
+}
+
+func nodeReady(node corev1.Node) bool {
+	for _, condition := range node.Status.Conditions {
+		if condition.Type == corev1.NodeReady {
+			return condition.Status == corev1.ConditionTrue
+		}
+	}
+	return false
+}
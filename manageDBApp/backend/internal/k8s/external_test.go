@@ -0,0 +1,59 @@
+package k8s
+
+import (
+	"context"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	k8stesting "k8s.io/client-go/testing"
+	"testing"
+)
+
+func nodePortService(nodePort int32) *corev1.Service {
+	return &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{Name: ExternalServiceName("render"), Namespace: "tenant"},
+		Spec: corev1.ServiceSpec{
+			Type:  corev1.ServiceTypeNodePort,
+			Ports: []corev1.ServicePort{{Port: 5432, NodePort: nodePort}},
+		},
+	}
+}
+
+func readyNode(name, internalIP string) *corev1.Node {
+	return &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Status: corev1.NodeStatus{
+			Conditions: []corev1.NodeCondition{{Type: corev1.NodeReady, Status: corev1.ConditionTrue}},
+			Addresses:  []corev1.NodeAddress{{Type: corev1.NodeInternalIP, Address: internalIP}},
+		},
+	}
+}
+
+func TestGetExternalAccessNodePort(t *testing.T) {
+	c, _ := newKubeBlocksClient(t, APIVersionV1, nodePortService(30432), readyNode("node-a", "192.0.2.10"))
+	access, err := c.GetExternalAccess(context.Background(), "render", "tenant")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if access.Status != "Ready" || access.Host != "192.0.2.10" || access.Port != "30432" {
+		t.Errorf("GetExternalAccess() = %+v, want Ready at 192.0.2.10:30432", access)
+	}
+}
+
+// A caller that may not list nodes still learns that the cluster is
+// exposed.
+func TestGetExternalAccessWithoutNodeAccess(t *testing.T) {
+	c, clientSet := newKubeBlocksClient(t, APIVersionV1, nodePortService(30432), readyNode("node-a", "192.0.2.10"))
+	clientSet.PrependReactor("list", "nodes", func(k8stesting.Action) (bool, runtime.Object, error) {
+		return true, nil, apierrors.NewForbidden(schema.GroupResource{Resource: "nodes"}, "", nil)
+	})
+	access, err := c.GetExternalAccess(context.Background(), "render", "tenant")
+	if err != nil {
+		t.Fatalf("GetExternalAccess() = %v", err)
+	}
+	if access.Status != "Pending" || access.Host != "" || access.Port != "30432" {
+		t.Errorf("GetExternalAccess() = %+v, want Pending without a host", access)
+	}
+}
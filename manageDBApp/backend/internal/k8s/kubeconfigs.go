@@ -0,0 +1,357 @@
+package k8s
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/clientcmd"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultKubeconfigTTL is how long a registered kubeconfig stays valid
+	// when the registration does not ask for a TTL.
+	DefaultKubeconfigTTL = 24 * time.Hour
+	// MaxKubeconfigTTL bounds the TTL a registration may ask for.
+	MaxKubeconfigTTL = 30 * 24 * time.Hour
+
+	// KubeconfigHandlePrefix marks the opaque handles returned on
+	// registration.
+	KubeconfigHandlePrefix = "kc-"
+
+	kubeconfigStoreLabel        = "mcp-db.sealos.io/kubeconfig"
+	kubeconfigExpiresAnnotation = "mcp-db.sealos.io/expires-at"
+	kubeconfigOwnerAnnotation   = "mcp-db.sealos.io/owner"
+	kubeconfigSecretKey         = "kubeconfig"
+)
+
+// ErrUnknownKubeconfig is returned for handles that were never registered,
+// were revoked or have expired.
+var ErrUnknownKubeconfig = errors.New("kubeconfig handle is unknown, revoked or expired")
+
+// ErrKubeconfigNotOwned is returned when a handle is used or revoked by
+// another caller than the one that registered it.
+var ErrKubeconfigNotOwned = errors.New("kubeconfig handle was registered by another caller")
+
+// KubeconfigStore keeps registered kubeconfigs encrypted so requests can
+// reference them by an opaque handle instead of carrying them. Every
+// registration belongs to the caller that made it, identified by owner,
+// which is empty for unauthenticated callers.
+type KubeconfigStore interface {
+	// Put stores kubeconfig for owner until ttl passes and returns its
+	// handle.
+	Put(ctx context.Context, owner, kubeconfig string, ttl time.Duration) (handle string, expiresAt time.Time, err error)
+	// Get returns the kubeconfig of handle, ErrUnknownKubeconfig, or
+	// ErrKubeconfigNotOwned when owner did not register it.
+	Get(ctx context.Context, owner, handle string) (string, error)
+	// Delete revokes handle, or returns ErrKubeconfigNotOwned when owner
+	// did not register it. Revoking an unknown handle is not an error.
+	Delete(ctx context.Context, owner, handle string) error
+}
+
+// SelectKubeconfigContext returns kubeconfig with its current context set
+// to name, checking that the context exists.
+func SelectKubeconfigContext(kubeconfig, name string) (string, error) {
+	config, err := clientcmd.Load([]byte(kubeconfig))
+	if err != nil {
+		return "", fmt.Errorf("invalid kubeconfig: %w", err)
+	}
+	if name == "" {
+		return kubeconfig, nil
+	}
+	if _, ok := config.Contexts[name]; !ok {
+		return "", fmt.Errorf("context %s does not exist in kubeconfig", name)
+	}
+	config.CurrentContext = name
+	data, err := clientcmd.Write(*config)
+	if err != nil {
+		return "", fmt.Errorf("failed to write kubeconfig: %w", err)
+	}
+	return string(data), nil
+}
+
+// kubeconfigCipher encrypts kubeconfigs with AES-256-GCM. The ciphertext is
+// bound to the handle and owner so it cannot be replayed under another
+// handle or opened for another caller.
+type kubeconfigCipher struct {
+	aead cipher.AEAD
+}
+
+// newKubeconfigCipher builds a cipher from a base64 encoded 32 byte key, or
+// from a random key when key is empty.
+func newKubeconfigCipher(key string) (*kubeconfigCipher, error) {
+	raw := make([]byte, 32)
+	if key == "" {
+		if _, err := rand.Read(raw); err != nil {
+			return nil, fmt.Errorf("failed to generate encryption key: %w", err)
+		}
+	} else {
+		decoded, err := base64.StdEncoding.DecodeString(key)
+		if err != nil || len(decoded) != 32 {
+			return nil, fmt.Errorf("encryption key must be 32 bytes encoded in base64")
+		}
+		raw = decoded
+	}
+	block, err := aes.NewCipher(raw)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	return &kubeconfigCipher{aead: aead}, nil
+}
+
+func (c *kubeconfigCipher) seal(handle, owner, plaintext string) ([]byte, error) {
+	nonce := make([]byte, c.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	return c.aead.Seal(nonce, nonce, []byte(plaintext), kubeconfigAAD(handle, owner)), nil
+}
+
+func (c *kubeconfigCipher) open(handle, owner string, ciphertext []byte) (string, error) {
+	size := c.aead.NonceSize()
+	if len(ciphertext) < size {
+		return "", ErrUnknownKubeconfig
+	}
+	plaintext, err := c.aead.Open(nil, ciphertext[:size], ciphertext[size:], kubeconfigAAD(handle, owner))
+	if err != nil {
+		return "", ErrUnknownKubeconfig
+	}
+	return string(plaintext), nil
+}
+
+// kubeconfigAAD is the additional data a kubeconfig is sealed with.
+// Registrations without an owner are bound to the handle alone.
+func kubeconfigAAD(handle, owner string) []byte {
+	if owner == "" {
+		return []byte(handle)
+	}
+	return []byte(handle + "\x00" + owner)
+}
+
+func newKubeconfigHandle() (string, error) {
+	raw := make([]byte, 24)
+	if _, err := rand.Read(raw); err != nil {
+		return "", fmt.Errorf("failed to generate handle: %w", err)
+	}
+	return KubeconfigHandlePrefix + hex.EncodeToString(raw), nil
+}
+
+type memoryKubeconfig struct {
+	ciphertext []byte
+	owner      string
+	expiresAt  time.Time
+}
+
+// MemoryKubeconfigStore keeps kubeconfigs in process memory. Registrations
+// are lost on restart.
+type MemoryKubeconfigStore struct {
+	cipher  *kubeconfigCipher
+	mu      sync.Mutex
+	entries map[string]memoryKubeconfig
+}
+
+// NewMemoryKubeconfigStore returns a store encrypting with key, a base64
+// encoded 32 byte key. An empty key generates one for the process.
+func NewMemoryKubeconfigStore(key string) (*MemoryKubeconfigStore, error) {
+	c, err := newKubeconfigCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return &MemoryKubeconfigStore{cipher: c, entries: map[string]memoryKubeconfig{}}, nil
+}
+
+func (s *MemoryKubeconfigStore) Put(ctx context.Context, owner, kubeconfig string, ttl time.Duration) (string, time.Time, error) {
+	handle, err := newKubeconfigHandle()
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	ciphertext, err := s.cipher.seal(handle, owner, kubeconfig)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	now := time.Now()
+	expiresAt := now.Add(ttl)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for h, entry := range s.entries {
+		if now.After(entry.expiresAt) {
+			delete(s.entries, h)
+		}
+	}
+	s.entries[handle] = memoryKubeconfig{ciphertext: ciphertext, owner: owner, expiresAt: expiresAt}
+	return handle, expiresAt, nil
+}
+
+func (s *MemoryKubeconfigStore) Get(ctx context.Context, owner, handle string) (string, error) {
+	s.mu.Lock()
+	entry, ok := s.entries[handle]
+	if ok && time.Now().After(entry.expiresAt) {
+		delete(s.entries, handle)
+		ok = false
+	}
+	s.mu.Unlock()
+	if !ok {
+		return "", ErrUnknownKubeconfig
+	}
+	if entry.owner != owner {
+		return "", ErrKubeconfigNotOwned
+	}
+	return s.cipher.open(handle, owner, entry.ciphertext)
+}
+
+func (s *MemoryKubeconfigStore) Delete(ctx context.Context, owner, handle string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	entry, ok := s.entries[handle]
+	if !ok {
+		return nil
+	}
+	if entry.owner != owner {
+		return ErrKubeconfigNotOwned
+	}
+	delete(s.entries, handle)
+	return nil
+}
+
+// SecretKubeconfigStore keeps kubeconfigs in Secrets of the namespace the
+// service runs in, so registrations survive restarts and are shared by
+// replicas. Secrets are named after a hash of the handle, never the handle
+// itself.
+type SecretKubeconfigStore struct {
+	cipher    *kubeconfigCipher
+	clientSet kubernetes.Interface
+	namespace string
+}
+
+// NewSecretKubeconfigStore returns a store in namespace encrypting with key,
+// which is required so Secrets stay readable across restarts.
+func NewSecretKubeconfigStore(clientSet kubernetes.Interface, namespace, key string) (*SecretKubeconfigStore, error) {
+	if key == "" {
+		return nil, fmt.Errorf("the secret kubeconfig store requires an encryption key")
+	}
+	c, err := newKubeconfigCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return &SecretKubeconfigStore{cipher: c, clientSet: clientSet, namespace: namespace}, nil
+}
+
+func kubeconfigSecretName(handle string) string {
+	sum := sha256.Sum256([]byte(handle))
+	return "kubeconfig-" + hex.EncodeToString(sum[:])[:40]
+}
+
+func (s *SecretKubeconfigStore) Put(ctx context.Context, owner, kubeconfig string, ttl time.Duration) (string, time.Time, error) {
+	handle, err := newKubeconfigHandle()
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	ciphertext, err := s.cipher.seal(handle, owner, kubeconfig)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	expiresAt := time.Now().Add(ttl).UTC()
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      kubeconfigSecretName(handle),
+			Namespace: s.namespace,
+			Labels:    map[string]string{kubeconfigStoreLabel: "true"},
+			Annotations: map[string]string{
+				kubeconfigExpiresAnnotation: expiresAt.Format(time.RFC3339),
+				kubeconfigOwnerAnnotation:   owner,
+			},
+		},
+		Type: corev1.SecretTypeOpaque,
+		Data: map[string][]byte{kubeconfigSecretKey: ciphertext},
+	}
+	if _, err := s.clientSet.CoreV1().Secrets(s.namespace).Create(ctx, secret, metav1.CreateOptions{}); err != nil {
+		return "", time.Time{}, fmt.Errorf("failed to store kubeconfig: %w", err)
+	}
+	s.purgeExpired(ctx)
+	return handle, expiresAt, nil
+}
+
+func (s *SecretKubeconfigStore) Get(ctx context.Context, owner, handle string) (string, error) {
+	secret, err := s.clientSet.CoreV1().Secrets(s.namespace).Get(ctx, kubeconfigSecretName(handle), metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return "", ErrUnknownKubeconfig
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to read kubeconfig: %w", err)
+	}
+	if secretExpired(secret, time.Now()) {
+		_ = s.delete(ctx, secret)
+		return "", ErrUnknownKubeconfig
+	}
+	if secret.Annotations[kubeconfigOwnerAnnotation] != owner {
+		return "", ErrKubeconfigNotOwned
+	}
+	return s.cipher.open(handle, owner, secret.Data[kubeconfigSecretKey])
+}
+
+func (s *SecretKubeconfigStore) Delete(ctx context.Context, owner, handle string) error {
+	secret, err := s.clientSet.CoreV1().Secrets(s.namespace).Get(ctx, kubeconfigSecretName(handle), metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to revoke kubeconfig: %w", err)
+	}
+	if secret.Annotations[kubeconfigOwnerAnnotation] != owner {
+		return ErrKubeconfigNotOwned
+	}
+	return s.delete(ctx, secret)
+}
+
+// delete removes the Secret of a registration unless it was replaced since
+// it was read.
+func (s *SecretKubeconfigStore) delete(ctx context.Context, secret *corev1.Secret) error {
+	err := s.clientSet.CoreV1().Secrets(s.namespace).Delete(ctx, secret.Name, metav1.DeleteOptions{
+		Preconditions: &metav1.Preconditions{UID: &secret.UID},
+	})
+	if err != nil && !apierrors.IsNotFound(err) {
+		return fmt.Errorf("failed to revoke kubeconfig: %w", err)
+	}
+	return nil
+}
+
+// Ping checks that the Secrets of the store can be listed.
+func (s *SecretKubeconfigStore) Ping(ctx context.Context) error {
+	_, err := s.clientSet.CoreV1().Secrets(s.namespace).List(ctx, metav1.ListOptions{LabelSelector: kubeconfigStoreLabel + "=true", Limit: 1})
+	return err
+}
+
+// purgeExpired deletes the Secrets of expired registrations. Failures are
+// ignored; expired Secrets are also rejected and removed on Get.
+func (s *SecretKubeconfigStore) purgeExpired(ctx context.Context) {
+	secrets, err := s.clientSet.CoreV1().Secrets(s.namespace).List(ctx, metav1.ListOptions{LabelSelector: kubeconfigStoreLabel + "=true"})
+	if err != nil {
+		return
+	}
+	now := time.Now()
+	for _, secret := range secrets.Items {
+		if secretExpired(&secret, now) {
+			_ = s.clientSet.CoreV1().Secrets(s.namespace).Delete(ctx, secret.Name, metav1.DeleteOptions{})
+		}
+	}
+}
+
+func secretExpired(secret *corev1.Secret, now time.Time) bool {
+	expiresAt, err := time.Parse(time.RFC3339, secret.Annotations[kubeconfigExpiresAnnotation])
+	return err != nil || now.After(expiresAt)
+}
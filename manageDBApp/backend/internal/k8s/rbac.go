@@ -2,9 +2,29 @@ package k8s
 
 import (
 	"context"
+	"fmt"
 	v1 "k8s.io/api/core/v1"
 	rbacv1 "k8s.io/api/rbac/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"mcp-db/pkg/types"
+	"time"
+)
+
+// ProviderLabel marks every object created on behalf of a database cluster;
+// its value is the cluster name.
+const ProviderLabel = "sealos-db-provider-cr"
+
+// ProviderTopologyLabel records the topology a cluster was created with.
+const ProviderTopologyLabel = "sealos-db-provider-topology"
+
+const (
+	// RBACReadyTimeout bounds how long WaitForRBAC waits for the objects
+	// of a cluster to become observable.
+	RBACReadyTimeout = 10 * time.Second
+	// RBACPollInterval is the delay between readiness checks.
+	RBACPollInterval = 200 * time.Millisecond
 )
 
 func (c *Client) CreateServiceAccount(ctx context.Context, name, namespace string) error {
@@ -12,7 +32,7 @@ func (c *Client) CreateServiceAccount(ctx context.Context, name, namespace strin
 		ObjectMeta: metav1.ObjectMeta{
 			Name: name,
 			Labels: map[string]string{
-				"sealos-db-provider-cr":        name,
+				ProviderLabel:                  name,
 				"app.kubernetes.io/instance":   name,
 				"app.kubernetes.io/managed-by": "kbcli",
 			},
@@ -22,34 +42,36 @@ func (c *Client) CreateServiceAccount(ctx context.Context, name, namespace strin
 	return err
 }
 
-func (c *Client) CreateRole(ctx context.Context, name, namespace string) error {
-	role := &rbacv1.Role{
+func (c *Client) CreateRole(ctx context.Context, name, namespace string, rules []rbacv1.PolicyRule) error {
+	_, err := c.ClientSet.RbacV1().Roles(namespace).Create(ctx, newRole(name, rules), metav1.CreateOptions{})
+	return err
+}
+
+func newRole(name string, rules []rbacv1.PolicyRule) *rbacv1.Role {
+	return &rbacv1.Role{
 		ObjectMeta: metav1.ObjectMeta{
 			Name: name,
 			Labels: map[string]string{
-				"sealos-db-provider-cr":        name,
+				ProviderLabel:                  name,
 				"app.kubernetes.io/instance":   name,
 				"app.kubernetes.io/managed-by": "kbcli",
 			},
 		},
-		Rules: []rbacv1.PolicyRule{
-			{
-				APIGroups: []string{"*"},
-				Resources: []string{"*"},
-				Verbs:     []string{"*"},
-			},
-		},
+		Rules: rules,
 	}
-	_, err := c.ClientSet.RbacV1().Roles(namespace).Create(ctx, role, metav1.CreateOptions{})
-	return err
 }
 
 func (c *Client) CreateRoleBinding(ctx context.Context, name, namespace string) error {
-	roleBinding := &rbacv1.RoleBinding{
+	_, err := c.ClientSet.RbacV1().RoleBindings(namespace).Create(ctx, newRoleBinding(name), metav1.CreateOptions{})
+	return err
+}
+
+func newRoleBinding(name string) *rbacv1.RoleBinding {
+	return &rbacv1.RoleBinding{
 		ObjectMeta: metav1.ObjectMeta{
 			Name: name,
 			Labels: map[string]string{
-				"sealos-db-provider-cr":        name,
+				ProviderLabel:                  name,
 				"app.kubernetes.io/instance":   name,
 				"app.kubernetes.io/managed-by": name,
 			},
@@ -66,6 +88,279 @@ func (c *Client) CreateRoleBinding(ctx context.Context, name, namespace string)
 			},
 		},
 	}
-	_, err := c.ClientSet.RbacV1().RoleBindings(namespace).Create(ctx, roleBinding, metav1.CreateOptions{})
+}
+
+// RBACConflictError is returned when an object with the name of a cluster's
+// ServiceAccount, Role or RoleBinding exists but was not created for it.
+type RBACConflictError struct {
+	Kind      string
+	Name      string
+	Namespace string
+}
+
+func (e *RBACConflictError) Error() string {
+	return fmt.Sprintf("%s %s/%s already exists and is not labelled %s=%s", e.Kind, e.Namespace, e.Name, ProviderLabel, e.Name)
+}
+
+// owned checks that an existing object carries ProviderLabel=name and so
+// may be adopted.
+func owned(kind, name, namespace string, labels map[string]string) error {
+	if labels[ProviderLabel] != name {
+		return &RBACConflictError{Kind: kind, Name: name, Namespace: namespace}
+	}
+	return nil
+}
+
+// CheckRBACOwnership returns an *RBACConflictError if the ServiceAccount,
+// Role or RoleBinding of a cluster exists without ProviderLabel=name.
+func (c *Client) CheckRBACOwnership(ctx context.Context, name, namespace string) error {
+	sa, err := c.ClientSet.CoreV1().ServiceAccounts(namespace).Get(ctx, name, metav1.GetOptions{})
+	if err == nil {
+		err = owned("ServiceAccount", name, namespace, sa.Labels)
+	}
+	if err != nil && !apierrors.IsNotFound(err) {
+		return err
+	}
+	role, err := c.ClientSet.RbacV1().Roles(namespace).Get(ctx, name, metav1.GetOptions{})
+	if err == nil {
+		err = owned("Role", name, namespace, role.Labels)
+	}
+	if err != nil && !apierrors.IsNotFound(err) {
+		return err
+	}
+	rb, err := c.ClientSet.RbacV1().RoleBindings(namespace).Get(ctx, name, metav1.GetOptions{})
+	if err == nil {
+		err = owned("RoleBinding", name, namespace, rb.Labels)
+	}
+	if err != nil && !apierrors.IsNotFound(err) {
+		return err
+	}
+	return nil
+}
+
+// EnsureServiceAccount creates the ServiceAccount, or adopts an existing one
+// labelled for the cluster. An existing one that is not labelled for it is an
+// *RBACConflictError. It reports whether the object was created.
+func (c *Client) EnsureServiceAccount(ctx context.Context, name, namespace string) (bool, error) {
+	err := c.CreateServiceAccount(ctx, name, namespace)
+	if err == nil || !apierrors.IsAlreadyExists(err) {
+		return err == nil, err
+	}
+	sa, err := c.ClientSet.CoreV1().ServiceAccounts(namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return false, err
+	}
+	return false, owned("ServiceAccount", name, namespace, sa.Labels)
+}
+
+// EnsureRole creates the Role, or adopts an existing one labelled for the
+// cluster by resetting its rules. An existing one that is not labelled for it
+// is an *RBACConflictError. It reports whether the object was created.
+func (c *Client) EnsureRole(ctx context.Context, name, namespace string, rules []rbacv1.PolicyRule) (bool, error) {
+	err := c.CreateRole(ctx, name, namespace, rules)
+	if err == nil || !apierrors.IsAlreadyExists(err) {
+		return err == nil, err
+	}
+	role, err := c.ClientSet.RbacV1().Roles(namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return false, err
+	}
+	if err := owned("Role", name, namespace, role.Labels); err != nil {
+		return false, err
+	}
+	desired := newRole(name, rules)
+	for k, v := range desired.Labels {
+		role.Labels[k] = v
+	}
+	role.Rules = desired.Rules
+	_, err = c.ClientSet.RbacV1().Roles(namespace).Update(ctx, role, metav1.UpdateOptions{})
+	return false, err
+}
+
+// EnsureRoleBinding creates the RoleBinding, or adopts an existing one
+// labelled for the cluster. The roleRef of a binding is immutable, so an
+// adopted binding pointing elsewhere is replaced. An existing one that is not
+// labelled for the cluster is an *RBACConflictError. It reports whether the
+// object was created.
+func (c *Client) EnsureRoleBinding(ctx context.Context, name, namespace string) (bool, error) {
+	err := c.CreateRoleBinding(ctx, name, namespace)
+	if err == nil || !apierrors.IsAlreadyExists(err) {
+		return err == nil, err
+	}
+	rb, err := c.ClientSet.RbacV1().RoleBindings(namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return false, err
+	}
+	if err := owned("RoleBinding", name, namespace, rb.Labels); err != nil {
+		return false, err
+	}
+	desired := newRoleBinding(name)
+	if rb.RoleRef != desired.RoleRef {
+		if err := c.DeleteRoleBinding(ctx, name, namespace); err != nil {
+			return false, err
+		}
+		return false, c.CreateRoleBinding(ctx, name, namespace)
+	}
+	for k, v := range desired.Labels {
+		rb.Labels[k] = v
+	}
+	rb.Subjects = desired.Subjects
+	_, err = c.ClientSet.RbacV1().RoleBindings(namespace).Update(ctx, rb, metav1.UpdateOptions{})
+	return false, err
+}
+
+// WaitForRBAC polls until the ServiceAccount, Role and RoleBinding of a
+// cluster can all be read back from the API server, or the timeout expires.
+func (c *Client) WaitForRBAC(ctx context.Context, name, namespace string, timeout time.Duration) error {
+	var missing string
+	err := wait.PollUntilContextTimeout(ctx, RBACPollInterval, timeout, true, func(ctx context.Context) (bool, error) {
+		checks := []struct {
+			kind string
+			get  func() error
+		}{
+			{"ServiceAccount", func() error {
+				_, err := c.ClientSet.CoreV1().ServiceAccounts(namespace).Get(ctx, name, metav1.GetOptions{})
+				return err
+			}},
+			{"Role", func() error {
+				_, err := c.ClientSet.RbacV1().Roles(namespace).Get(ctx, name, metav1.GetOptions{})
+				return err
+			}},
+			{"RoleBinding", func() error {
+				_, err := c.ClientSet.RbacV1().RoleBindings(namespace).Get(ctx, name, metav1.GetOptions{})
+				return err
+			}},
+		}
+		for _, check := range checks {
+			err := check.get()
+			if apierrors.IsNotFound(err) {
+				missing = check.kind
+				return false, nil
+			}
+			if err != nil {
+				return false, fmt.Errorf("failed to get %s: %w", check.kind, err)
+			}
+		}
+		return true, nil
+	})
+	if err != nil && missing != "" && wait.Interrupted(err) {
+		return fmt.Errorf("timed out waiting for %s %s/%s: %w", missing, namespace, name, err)
+	}
+	return err
+}
+
+func (c *Client) DeleteServiceAccount(ctx context.Context, name, namespace string) error {
+	err := c.ClientSet.CoreV1().ServiceAccounts(namespace).Delete(ctx, name, metav1.DeleteOptions{})
+	if apierrors.IsNotFound(err) {
+		return nil
+	}
 	return err
 }
+
+func (c *Client) DeleteRole(ctx context.Context, name, namespace string) error {
+	err := c.ClientSet.RbacV1().Roles(namespace).Delete(ctx, name, metav1.DeleteOptions{})
+	if apierrors.IsNotFound(err) {
+		return nil
+	}
+	return err
+}
+
+func (c *Client) DeleteRoleBinding(ctx context.Context, name, namespace string) error {
+	err := c.ClientSet.RbacV1().RoleBindings(namespace).Delete(ctx, name, metav1.DeleteOptions{})
+	if apierrors.IsNotFound(err) {
+		return nil
+	}
+	return err
+}
+
+// DeleteRBAC removes every ServiceAccount, Role and RoleBinding labelled
+// with ProviderLabel=name.
+func (c *Client) DeleteRBAC(ctx context.Context, name, namespace string) error {
+	opts := metav1.ListOptions{LabelSelector: fmt.Sprintf("%s=%s", ProviderLabel, name)}
+
+	bindings, err := c.ClientSet.RbacV1().RoleBindings(namespace).List(ctx, opts)
+	if err != nil {
+		return fmt.Errorf("failed to list RoleBindings: %w", err)
+	}
+	for _, rb := range bindings.Items {
+		if err := c.DeleteRoleBinding(ctx, rb.Name, namespace); err != nil {
+			return fmt.Errorf("failed to delete RoleBinding %s: %w", rb.Name, err)
+		}
+	}
+	roles, err := c.ClientSet.RbacV1().Roles(namespace).List(ctx, opts)
+	if err != nil {
+		return fmt.Errorf("failed to list Roles: %w", err)
+	}
+	for _, role := range roles.Items {
+		if err := c.DeleteRole(ctx, role.Name, namespace); err != nil {
+			return fmt.Errorf("failed to delete Role %s: %w", role.Name, err)
+		}
+	}
+	accounts, err := c.ClientSet.CoreV1().ServiceAccounts(namespace).List(ctx, opts)
+	if err != nil {
+		return fmt.Errorf("failed to list ServiceAccounts: %w", err)
+	}
+	for _, sa := range accounts.Items {
+		if err := c.DeleteServiceAccount(ctx, sa.Name, namespace); err != nil {
+			return fmt.Errorf("failed to delete ServiceAccount %s: %w", sa.Name, err)
+		}
+	}
+	return nil
+}
+
+// FindOrphanedRBAC reports provider-labelled RBAC objects in namespace whose
+// owning cluster no longer exists.
+func (c *Client) FindOrphanedRBAC(ctx context.Context, namespace string) (*types.GarbageReport, error) {
+	gvr, err := c.clusterGVR()
+	if err != nil {
+		return nil, err
+	}
+	clusters, err := c.DynamicClient.Resource(gvr).Namespace(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list clusters: %w", err)
+	}
+	existing := make(map[string]bool, len(clusters.Items))
+	for _, cluster := range clusters.Items {
+		existing[cluster.GetName()] = true
+	}
+	orphaned := func(labels map[string]string) bool {
+		owner, ok := labels[ProviderLabel]
+		return ok && !existing[owner]
+	}
+
+	opts := metav1.ListOptions{LabelSelector: ProviderLabel}
+	report := &types.GarbageReport{
+		Namespace:       namespace,
+		ServiceAccounts: []string{},
+		Roles:           []string{},
+		RoleBindings:    []string{},
+	}
+	accounts, err := c.ClientSet.CoreV1().ServiceAccounts(namespace).List(ctx, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list ServiceAccounts: %w", err)
+	}
+	for _, sa := range accounts.Items {
+		if orphaned(sa.Labels) {
+			report.ServiceAccounts = append(report.ServiceAccounts, sa.Name)
+		}
+	}
+	roles, err := c.ClientSet.RbacV1().Roles(namespace).List(ctx, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list Roles: %w", err)
+	}
+	for _, role := range roles.Items {
+		if orphaned(role.Labels) {
+			report.Roles = append(report.Roles, role.Name)
+		}
+	}
+	bindings, err := c.ClientSet.RbacV1().RoleBindings(namespace).List(ctx, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list RoleBindings: %w", err)
+	}
+	for _, rb := range bindings.Items {
+		if orphaned(rb.Labels) {
+			report.RoleBindings = append(report.RoleBindings, rb.Name)
+		}
+	}
+	return report, nil
+}
@@ -0,0 +1,98 @@
+package k8s
+
+import (
+	"context"
+	"errors"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// notFoundTimes makes the first n gets of resource fail with NotFound.
+func notFoundTimes(clientSet *fake.Clientset, resource string, n int32) *atomic.Int32 {
+	var calls atomic.Int32
+	clientSet.PrependReactor("get", resource, func(action k8stesting.Action) (bool, runtime.Object, error) {
+		if calls.Add(1) > n {
+			return false, nil, nil
+		}
+		name := action.(k8stesting.GetAction).GetName()
+		return true, nil, apierrors.NewNotFound(schema.GroupResource{Resource: resource}, name)
+	})
+	return &calls
+}
+
+func createRBAC(t *testing.T, c *Client, name, namespace string) {
+	t.Helper()
+	ctx := context.Background()
+	if err := c.CreateServiceAccount(ctx, name, namespace); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.CreateRole(ctx, name, namespace, nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.CreateRoleBinding(ctx, name, namespace); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestWaitForRBACReady(t *testing.T) {
+	clientSet := fake.NewSimpleClientset()
+	c := &Client{ClientSet: clientSet}
+	createRBAC(t, c, "db", "ns")
+	if err := c.WaitForRBAC(context.Background(), "db", "ns", time.Second); err != nil {
+		t.Fatalf("WaitForRBAC() = %v, want nil", err)
+	}
+}
+
+func TestWaitForRBACSlow(t *testing.T) {
+	clientSet := fake.NewSimpleClientset()
+	c := &Client{ClientSet: clientSet}
+	createRBAC(t, c, "db", "ns")
+	calls := notFoundTimes(clientSet, "rolebindings", 3)
+	if err := c.WaitForRBAC(context.Background(), "db", "ns", 5*time.Second); err != nil {
+		t.Fatalf("WaitForRBAC() = %v, want nil", err)
+	}
+	if got := calls.Load(); got != 4 {
+		t.Errorf("RoleBinding was read %d times, want 4", got)
+	}
+}
+
+func TestWaitForRBACTimeout(t *testing.T) {
+	clientSet := fake.NewSimpleClientset()
+	c := &Client{ClientSet: clientSet}
+	createRBAC(t, c, "db", "ns")
+	notFoundTimes(clientSet, "roles", 1000)
+	err := c.WaitForRBAC(context.Background(), "db", "ns", 500*time.Millisecond)
+	if err == nil || !strings.Contains(err.Error(), "Role ns/db") {
+		t.Fatalf("WaitForRBAC() = %v, want a timeout naming the Role", err)
+	}
+}
+
+func TestWaitForRBACFailure(t *testing.T) {
+	clientSet := fake.NewSimpleClientset()
+	c := &Client{ClientSet: clientSet}
+	createRBAC(t, c, "db", "ns")
+	forbidden := apierrors.NewForbidden(schema.GroupResource{Resource: "serviceaccounts"}, "db", errors.New("denied"))
+	var calls atomic.Int32
+	clientSet.PrependReactor("get", "serviceaccounts", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		calls.Add(1)
+		return true, nil, forbidden
+	})
+	start := time.Now()
+	err := c.WaitForRBAC(context.Background(), "db", "ns", 5*time.Second)
+	if !apierrors.IsForbidden(err) {
+		t.Fatalf("WaitForRBAC() = %v, want the Forbidden error", err)
+	}
+	if got := calls.Load(); got != 1 {
+		t.Errorf("ServiceAccount was read %d times, want 1", got)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("WaitForRBAC() took %s, want it to fail without polling", elapsed)
+	}
+}
@@ -0,0 +1,117 @@
+package k8s
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"sigs.k8s.io/yaml"
+	"sync"
+	"time"
+)
+
+// EngineFile is the schema of the engine registry file. It can be YAML or
+// JSON and is usually mounted from a ConfigMap:
+//
+//	engines:
+//	  postgresql:
+//	    definition: postgresql
+//	    versionTemplate: postgresql-%s
+//	    defaultVersion: 14.8.0
+//	    allowedVersions: ["14.8.0", "12.15.0"]
+//	    components:
+//	      - name: postgresql
+//	        componentDefRef: postgresql
+//	    resources:
+//	      cpu: 1000m
+//	      memory: 1024Mi
+//	      storage: 3Gi
+//
+// Unknown fields are rejected. Engines without rules get the built-in rules
+// of the same type, or the default least-privilege rules.
+type EngineFile struct {
+	Engines map[string]DatabaseConfig `json:"engines"`
+}
+
+var (
+	engineFileMu   sync.Mutex
+	engineFilePath string
+	engineFileMod  time.Time
+)
+
+// LoadEngineFile replaces the engine registry with the contents of path and
+// remembers path for ReloadEngineFile and WatchEngineFile.
+func LoadEngineFile(path string) error {
+	engineFileMu.Lock()
+	defer engineFileMu.Unlock()
+	return loadEngineFileLocked(path)
+}
+
+// ReloadEngineFile reloads the engine file passed to LoadEngineFile.
+func ReloadEngineFile() error {
+	engineFileMu.Lock()
+	defer engineFileMu.Unlock()
+	if engineFilePath == "" {
+		return fmt.Errorf("no engine file configured")
+	}
+	return loadEngineFileLocked(engineFilePath)
+}
+
+func loadEngineFileLocked(path string) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return fmt.Errorf("failed to read engine file: %w", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read engine file: %w", err)
+	}
+	var file EngineFile
+	if err := yaml.UnmarshalStrict(data, &file); err != nil {
+		return fmt.Errorf("failed to parse engine file %s: %w", path, err)
+	}
+	if err := SetEngines(file.Engines); err != nil {
+		return fmt.Errorf("engine file %s: %w", path, err)
+	}
+	engineFilePath = path
+	engineFileMod = info.ModTime()
+	slog.Info("Loaded engine file", "path", path, "engines", len(file.Engines))
+	return nil
+}
+
+// WatchEngineFile polls the loaded engine file and reloads it whenever its
+// modification time changes, until ctx is done. Polling rather than inotify
+// keeps working when the file is a ConfigMap volume, which is updated by
+// swapping symlinks. A file that fails validation is logged and the current
+// registry is kept.
+func WatchEngineFile(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		engineFileMu.Lock()
+		path, mod := engineFilePath, engineFileMod
+		engineFileMu.Unlock()
+		if path == "" {
+			continue
+		}
+		info, err := os.Stat(path)
+		if err != nil {
+			slog.Error("Failed to stat engine file", "path", path, "error", err)
+			continue
+		}
+		if info.ModTime().Equal(mod) {
+			continue
+		}
+		if err := ReloadEngineFile(); err != nil {
+			slog.Error("Failed to reload engine file", "path", path, "error", err)
+			engineFileMu.Lock()
+			engineFileMod = info.ModTime()
+			engineFileMu.Unlock()
+		}
+	}
+}
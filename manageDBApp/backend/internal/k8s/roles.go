@@ -0,0 +1,74 @@
+package k8s
+
+import (
+	"fmt"
+	rbacv1 "k8s.io/api/rbac/v1"
+	"os"
+	"sigs.k8s.io/yaml"
+)
+
+// defaultRoleRules are the permissions the KubeBlocks sidecars of a
+// database pod need: reporting events, labelling pods with their role,
+// keeping HA state in ConfigMaps and holding the HA leader lease.
+var defaultRoleRules = []rbacv1.PolicyRule{
+	{
+		APIGroups: []string{""},
+		Resources: []string{"events"},
+		Verbs:     []string{"create", "patch"},
+	},
+	{
+		APIGroups: []string{""},
+		Resources: []string{"pods"},
+		Verbs:     []string{"get", "list", "watch", "patch", "update"},
+	},
+	{
+		APIGroups: []string{""},
+		Resources: []string{"configmaps"},
+		Verbs:     []string{"get", "list", "watch", "create", "update", "patch"},
+	},
+	{
+		APIGroups: []string{"coordination.k8s.io"},
+		Resources: []string{"leases"},
+		Verbs:     []string{"get", "create", "update"},
+	},
+}
+
+// postgresqlRoleRules extend defaultRoleRules with the Endpoints Patroni
+// keeps its leader in and the Services it creates for them. The ConfigMaps
+// of its distributed configuration store are in defaultRoleRules.
+var postgresqlRoleRules = append(append([]rbacv1.PolicyRule{}, defaultRoleRules...),
+	rbacv1.PolicyRule{
+		APIGroups: []string{""},
+		Resources: []string{"endpoints"},
+		Verbs:     []string{"get", "list", "watch", "create", "update", "patch", "delete"},
+	},
+	rbacv1.PolicyRule{
+		APIGroups: []string{""},
+		Resources: []string{"services"},
+		Verbs:     []string{"get", "list", "create"},
+	},
+)
+
+// LoadRoleRules overrides the Role rules of the engine registry from a YAML
+// or JSON file mapping database type to a list of rbac/v1 PolicyRules:
+//
+//	postgresql:
+//	  - apiGroups: [""]
+//	    resources: ["configmaps"]
+//	    verbs: ["get", "update"]
+//
+// Types missing from the file keep their registry rules. The overrides
+// survive reloads of the engine file.
+func LoadRoleRules(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read role rules: %w", err)
+	}
+	var overrides map[string][]rbacv1.PolicyRule
+	if err := yaml.UnmarshalStrict(data, &overrides); err != nil {
+		return fmt.Errorf("failed to parse role rules %s: %w", path, err)
+	}
+	enginesMu.Lock()
+	defer enginesMu.Unlock()
+	return setEnginesLocked(engines, overrides)
+}
@@ -0,0 +1,223 @@
+package k8s
+
+import (
+	"fmt"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"mcp-db/pkg/types"
+	"sort"
+	"strings"
+)
+
+const (
+	PodAntiAffinityPreferred = "Preferred"
+	PodAntiAffinityRequired  = "Required"
+
+	TenancySharedNode    = "SharedNode"
+	TenancyDedicatedNode = "DedicatedNode"
+
+	DefaultTopologyKey = "kubernetes.io/hostname"
+)
+
+var (
+	tolerationOperators = []string{"Exists", "Equal"}
+	tolerationEffects   = []string{"NoSchedule", "PreferNoSchedule", "NoExecute"}
+)
+
+// ValidateScheduling checks the node labels, tolerations, anti-affinity,
+// tenancy and topology keys of a create request before anything is
+// submitted to the API server.
+func ValidateScheduling(req *types.CreateDatabaseRequest) error {
+	for key, value := range req.NodeLabels {
+		if errs := validation.IsQualifiedName(key); len(errs) > 0 {
+			return fmt.Errorf("invalid node label key %q: %s", key, strings.Join(errs, "; "))
+		}
+		if errs := validation.IsValidLabelValue(value); len(errs) > 0 {
+			return fmt.Errorf("invalid node label value %q for %s: %s", value, key, strings.Join(errs, "; "))
+		}
+	}
+	for i, t := range req.Tolerations {
+		if t.Key != "" {
+			if errs := validation.IsQualifiedName(t.Key); len(errs) > 0 {
+				return fmt.Errorf("invalid key in toleration %d: %s", i, strings.Join(errs, "; "))
+			}
+		}
+		operator := t.Operator
+		if operator == "" {
+			operator = "Equal"
+		}
+		if !contains(tolerationOperators, operator) {
+			return fmt.Errorf("toleration %d operator must be one of %s", i, strings.Join(tolerationOperators, ", "))
+		}
+		if operator == "Exists" && t.Value != "" {
+			return fmt.Errorf("toleration %d with operator Exists must not set a value", i)
+		}
+		if operator == "Equal" && t.Key == "" {
+			return fmt.Errorf("toleration %d with operator Equal needs a key", i)
+		}
+		if t.Value != "" {
+			if errs := validation.IsValidLabelValue(t.Value); len(errs) > 0 {
+				return fmt.Errorf("invalid value in toleration %d: %s", i, strings.Join(errs, "; "))
+			}
+		}
+		if t.Effect != "" && !contains(tolerationEffects, t.Effect) {
+			return fmt.Errorf("toleration %d effect must be one of %s", i, strings.Join(tolerationEffects, ", "))
+		}
+		if t.TolerationSeconds != nil && t.Effect != "NoExecute" {
+			return fmt.Errorf("toleration %d sets toleration_seconds, which requires effect NoExecute", i)
+		}
+	}
+	if a := req.PodAntiAffinity; a != "" && a != PodAntiAffinityPreferred && a != PodAntiAffinityRequired {
+		return fmt.Errorf("pod anti-affinity must be %s or %s", PodAntiAffinityPreferred, PodAntiAffinityRequired)
+	}
+	if t := req.Tenancy; t != "" && t != TenancySharedNode && t != TenancyDedicatedNode {
+		return fmt.Errorf("tenancy must be %s or %s", TenancySharedNode, TenancyDedicatedNode)
+	}
+	for _, key := range req.TopologyKeys {
+		if errs := validation.IsQualifiedName(key); len(errs) > 0 {
+			return fmt.Errorf("invalid topology key %q: %s", key, strings.Join(errs, "; "))
+		}
+	}
+	return nil
+}
+
+// schedulingDefaults returns the anti-affinity, tenancy and topology keys of
+// req with the service defaults applied.
+func schedulingDefaults(req *types.CreateDatabaseRequest) (antiAffinity, tenancy string, topologyKeys []string) {
+	antiAffinity, tenancy, topologyKeys = req.PodAntiAffinity, req.Tenancy, req.TopologyKeys
+	if antiAffinity == "" {
+		antiAffinity = PodAntiAffinityPreferred
+	}
+	if tenancy == "" {
+		tenancy = TenancySharedNode
+	}
+	if len(topologyKeys) == 0 {
+		topologyKeys = []string{DefaultTopologyKey}
+	}
+	return antiAffinity, tenancy, topologyKeys
+}
+
+func renderTolerations(tolerations []types.Toleration) []interface{} {
+	result := make([]interface{}, 0, len(tolerations))
+	for _, t := range tolerations {
+		toleration := map[string]interface{}{}
+		if t.Key != "" {
+			toleration["key"] = t.Key
+		}
+		if t.Operator != "" {
+			toleration["operator"] = t.Operator
+		}
+		if t.Value != "" {
+			toleration["value"] = t.Value
+		}
+		if t.Effect != "" {
+			toleration["effect"] = t.Effect
+		}
+		if t.TolerationSeconds != nil {
+			toleration["tolerationSeconds"] = *t.TolerationSeconds
+		}
+		result = append(result, toleration)
+	}
+	return result
+}
+
+// renderAffinityV1Alpha1 renders the KubeBlocks v1alpha1 spec.affinity,
+// which KubeBlocks expands into pod affinity itself.
+func renderAffinityV1Alpha1(req *types.CreateDatabaseRequest) map[string]interface{} {
+	antiAffinity, tenancy, topologyKeys := schedulingDefaults(req)
+	nodeLabels := map[string]interface{}{}
+	for key, value := range req.NodeLabels {
+		nodeLabels[key] = value
+	}
+	keys := make([]interface{}, 0, len(topologyKeys))
+	for _, key := range topologyKeys {
+		keys = append(keys, key)
+	}
+	return map[string]interface{}{
+		"nodeLabels":      nodeLabels,
+		"podAntiAffinity": antiAffinity,
+		"tenancy":         tenancy,
+		"topologyKeys":    keys,
+	}
+}
+
+// renderSchedulingPolicyV1 renders the v1 spec.schedulingPolicy. The v1 API
+// takes a plain corev1 Affinity, so node labels, anti-affinity and tenancy
+// are expanded here the way v1alpha1 KubeBlocks did.
+func renderSchedulingPolicyV1(req *types.CreateDatabaseRequest) map[string]interface{} {
+	antiAffinity, tenancy, topologyKeys := schedulingDefaults(req)
+	affinity := map[string]interface{}{}
+
+	if len(req.NodeLabels) > 0 {
+		keys := make([]string, 0, len(req.NodeLabels))
+		for key := range req.NodeLabels {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		expressions := make([]interface{}, 0, len(keys))
+		for _, key := range keys {
+			expressions = append(expressions, map[string]interface{}{
+				"key":      key,
+				"operator": "In",
+				"values":   []interface{}{req.NodeLabels[key]},
+			})
+		}
+		affinity["nodeAffinity"] = map[string]interface{}{
+			"requiredDuringSchedulingIgnoredDuringExecution": map[string]interface{}{
+				"nodeSelectorTerms": []interface{}{
+					map[string]interface{}{"matchExpressions": expressions},
+				},
+			},
+		}
+	}
+
+	selector := map[string]interface{}{
+		"matchLabels": map[string]interface{}{
+			"app.kubernetes.io/instance": req.Name,
+		},
+	}
+	if tenancy == TenancyDedicatedNode {
+		// Keep every other KubeBlocks pod off the nodes of this cluster.
+		selector = map[string]interface{}{
+			"matchLabels": map[string]interface{}{
+				"app.kubernetes.io/managed-by": "kubeblocks",
+			},
+		}
+	}
+	terms := make([]interface{}, 0, len(topologyKeys))
+	for _, key := range topologyKeys {
+		term := map[string]interface{}{
+			"labelSelector": selector,
+			"topologyKey":   key,
+		}
+		if antiAffinity == PodAntiAffinityRequired || tenancy == TenancyDedicatedNode {
+			terms = append(terms, term)
+		} else {
+			terms = append(terms, map[string]interface{}{
+				"weight":          int64(100),
+				"podAffinityTerm": term,
+			})
+		}
+	}
+	if antiAffinity == PodAntiAffinityRequired || tenancy == TenancyDedicatedNode {
+		affinity["podAntiAffinity"] = map[string]interface{}{
+			"requiredDuringSchedulingIgnoredDuringExecution": terms,
+		}
+	} else {
+		affinity["podAntiAffinity"] = map[string]interface{}{
+			"preferredDuringSchedulingIgnoredDuringExecution": terms,
+		}
+	}
+	return map[string]interface{}{
+		"affinity":    affinity,
+		"tolerations": renderTolerations(req.Tolerations),
+	}
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,131 @@
+package k8s
+
+import (
+	"context"
+	"fmt"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"mcp-db/pkg/types"
+	"strings"
+)
+
+// DataVolume is the volumeClaimTemplate every component gets.
+const DataVolume = "data"
+
+// ValidateVolumes checks the extra volumes of a create request against the
+// volumes the engine supports.
+func ValidateVolumes(req *types.CreateDatabaseRequest, dbConfig DatabaseConfig) error {
+	seen := map[string]bool{}
+	for _, volume := range req.Volumes {
+		if volume.Name == DataVolume {
+			return fmt.Errorf("volume %s is always created, size it with the storage field", DataVolume)
+		}
+		if !contains(dbConfig.Volumes, volume.Name) {
+			if len(dbConfig.Volumes) == 0 {
+				return fmt.Errorf("database type %s supports no extra volumes", req.Type)
+			}
+			return fmt.Errorf("unsupported volume %s for %s, supported volumes: %s", volume.Name, req.Type, strings.Join(dbConfig.Volumes, ", "))
+		}
+		if seen[volume.Name] {
+			return fmt.Errorf("duplicate volume %s", volume.Name)
+		}
+		seen[volume.Name] = true
+		if _, err := resource.ParseQuantity(volume.Storage); err != nil {
+			return fmt.Errorf("invalid storage %q for volume %s: %w", volume.Storage, volume.Name, err)
+		}
+	}
+	return nil
+}
+
+// ValidateStorageClass checks that the StorageClass exists and allows volume
+// expansion, so the database can be resized later.
+func (c *Client) ValidateStorageClass(ctx context.Context, name string) error {
+	class, err := c.ClientSet.StorageV1().StorageClasses().Get(ctx, name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return &InvalidRequestError{Err: fmt.Errorf("storage class %s does not exist", name)}
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get storage class %s: %w", name, err)
+	}
+	if class.AllowVolumeExpansion == nil || !*class.AllowVolumeExpansion {
+		return &InvalidRequestError{Err: fmt.Errorf("storage class %s does not allow volume expansion", name)}
+	}
+	return nil
+}
+
+// buildVolumeClaimTemplates renders the data volume and, for the main
+// component, the extra volumes of req.
+func buildVolumeClaimTemplates(req *types.CreateDatabaseRequest, main bool) []interface{} {
+	volumes := []types.VolumeRequest{{Name: DataVolume, Storage: req.Storage}}
+	if main {
+		volumes = append(volumes, req.Volumes...)
+	}
+	templates := make([]interface{}, 0, len(volumes))
+	for _, volume := range volumes {
+		spec := map[string]interface{}{
+			"accessModes": []interface{}{
+				"ReadWriteOnce",
+			},
+			"resources": map[string]interface{}{
+				"requests": map[string]interface{}{
+					"storage": volume.Storage,
+				},
+			},
+		}
+		if req.StorageClass != "" {
+			spec["storageClassName"] = req.StorageClass
+		}
+		templates = append(templates, map[string]interface{}{
+			"name": volume.Name,
+			"spec": spec,
+		})
+	}
+	return templates
+}
+
+// listVolumes returns the PersistentVolumeClaims of KubeBlocks clusters in
+// namespace grouped by cluster name, optionally limited to one cluster. The
+// capacity and storage class are the ones actually bound.
+func (c *Client) listVolumes(ctx context.Context, namespace, cluster string) (map[string][]types.VolumeInfo, error) {
+	selector := "app.kubernetes.io/managed-by=kubeblocks"
+	if cluster != "" {
+		selector += ",app.kubernetes.io/instance=" + cluster
+	}
+	claims, err := c.ClientSet.CoreV1().PersistentVolumeClaims(namespace).List(ctx, metav1.ListOptions{LabelSelector: selector})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list persistent volume claims: %w", err)
+	}
+	result := map[string][]types.VolumeInfo{}
+	for _, claim := range claims.Items {
+		instance := claim.Labels["app.kubernetes.io/instance"]
+		info := types.VolumeInfo{
+			Name:      claim.Name,
+			Volume:    claim.Labels["apps.kubeblocks.io/vct-name"],
+			Component: claim.Labels["apps.kubeblocks.io/component-name"],
+			Status:    string(claim.Status.Phase),
+		}
+		if claim.Spec.StorageClassName != nil {
+			info.StorageClass = *claim.Spec.StorageClassName
+		}
+		if capacity, ok := claim.Status.Capacity["storage"]; ok {
+			info.Capacity = capacity.String()
+		}
+		result[instance] = append(result[instance], info)
+	}
+	return result, nil
+}
+
+// InvalidRequestError marks errors caused by the request rather than the
+// target cluster.
+type InvalidRequestError struct {
+	Err error
+}
+
+func (e *InvalidRequestError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *InvalidRequestError) Unwrap() error {
+	return e.Err
+}
@@ -0,0 +1,146 @@
+package k8s
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+const (
+	TopologyStandalone = "standalone"
+
+	// DefaultSwitchPolicy is the switchPolicy of v1alpha1 components that
+	// do not ask for another one.
+	DefaultSwitchPolicy = "Noop"
+)
+
+// TopologyConfig describes one deployment shape of an engine, such as a
+// primary/replica pair or a sharded cluster.
+type TopologyConfig struct {
+	// Components replaces the engine components for this topology when set,
+	// even to an empty list. Unless the topology is sharded, the first
+	// component is the data component sized by the request replicas.
+	Components []ComponentConfig `json:"components,omitempty"`
+	// DefaultReplicas and MinReplicas apply to the data component, or to
+	// every shard when Sharding is set.
+	DefaultReplicas int  `json:"defaultReplicas,omitempty"`
+	MinReplicas     int  `json:"minReplicas,omitempty"`
+	OddReplicas     bool `json:"oddReplicas,omitempty"`
+	// SwitchPolicy is the v1alpha1 switchPolicy of the data component.
+	SwitchPolicy string `json:"switchPolicy,omitempty"`
+	// ClusterTopology selects a topology of the v1 ClusterDefinition.
+	ClusterTopology string          `json:"clusterTopology,omitempty"`
+	Sharding        *ShardingConfig `json:"sharding,omitempty"`
+}
+
+// ShardingConfig renders a shardingSpecs entry whose shards all share one
+// component template.
+type ShardingConfig struct {
+	Name          string `json:"name"`
+	DefRef        string `json:"componentDef"`
+	DefaultShards int    `json:"defaultShards,omitempty"`
+	MinShards     int    `json:"minShards,omitempty"`
+}
+
+// Topology is a TopologyConfig resolved against a create request.
+type Topology struct {
+	Name            string
+	Components      []ComponentConfig
+	SwitchPolicy    string
+	ClusterTopology string
+	Sharding        *ShardingConfig
+	Shards          int
+	Replicas        int
+}
+
+// TopologyNames lists the topologies of an engine, always including
+// standalone.
+func (d DatabaseConfig) TopologyNames() []string {
+	names := []string{TopologyStandalone}
+	for name := range d.Topologies {
+		if name != TopologyStandalone {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names[1:])
+	return names
+}
+
+// ResolveTopology validates the requested topology, replicas and shards for
+// an engine and fills in defaults. An empty name selects the engine default
+// topology, and standalone is always available.
+func ResolveTopology(dbConfig DatabaseConfig, name string, replicas, shards int) (*Topology, error) {
+	if name == "" {
+		name = dbConfig.DefaultTopology
+	}
+	if name == "" {
+		name = TopologyStandalone
+	}
+	config, ok := dbConfig.Topologies[name]
+	if !ok && name != TopologyStandalone {
+		return nil, fmt.Errorf("unsupported topology %s, valid topologies: %s", name, strings.Join(dbConfig.TopologyNames(), ", "))
+	}
+	if name == TopologyStandalone && !ok {
+		config = TopologyConfig{DefaultReplicas: 1, MinReplicas: 1}
+	}
+	topology := &Topology{
+		Name:            name,
+		Components:      dbConfig.Components,
+		SwitchPolicy:    config.SwitchPolicy,
+		ClusterTopology: config.ClusterTopology,
+		Sharding:        config.Sharding,
+	}
+	if config.Components != nil {
+		topology.Components = config.Components
+	}
+	if topology.SwitchPolicy == "" {
+		topology.SwitchPolicy = DefaultSwitchPolicy
+	}
+
+	if replicas == 0 {
+		replicas = config.DefaultReplicas
+	}
+	if replicas == 0 {
+		replicas = 1
+	}
+	if name == TopologyStandalone && replicas != 1 {
+		return nil, fmt.Errorf("topology %s runs exactly one replica", name)
+	}
+	if replicas < config.MinReplicas {
+		return nil, fmt.Errorf("topology %s needs at least %d replicas", name, config.MinReplicas)
+	}
+	if config.OddReplicas && replicas%2 == 0 {
+		return nil, fmt.Errorf("topology %s needs an odd number of replicas", name)
+	}
+	topology.Replicas = replicas
+
+	if config.Sharding == nil {
+		if shards != 0 {
+			return nil, fmt.Errorf("topology %s is not sharded", name)
+		}
+		return topology, nil
+	}
+	if shards == 0 {
+		shards = config.Sharding.DefaultShards
+	}
+	if shards == 0 {
+		shards = 1
+	}
+	if shards < config.Sharding.MinShards {
+		return nil, fmt.Errorf("topology %s needs at least %d shards", name, config.Sharding.MinShards)
+	}
+	topology.Shards = shards
+	return topology, nil
+}
+
+// componentReplicas returns the replicas of the i-th component: the data
+// component follows the request, the others their configured count.
+func (t *Topology) componentReplicas(i int, component ComponentConfig) int {
+	if i == 0 && t.Sharding == nil {
+		return t.Replicas
+	}
+	if component.Replicas > 0 {
+		return component.Replicas
+	}
+	return 1
+}
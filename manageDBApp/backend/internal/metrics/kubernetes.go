@@ -0,0 +1,93 @@
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// InstrumentKubernetes wraps the transport of a Kubernetes client to
+// measure its requests. It is meant for rest.Config.Wrap.
+func InstrumentKubernetes(next http.RoundTripper) http.RoundTripper {
+	return kubeTransport{next: next}
+}
+
+type kubeTransport struct {
+	next http.RoundTripper
+}
+
+func (t kubeTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	verb, resource := kubeRequestInfo(req)
+	start := time.Now()
+	resp, err := t.next.RoundTrip(req)
+	kubeDuration.WithLabelValues(verb, resource).Observe(time.Since(start).Seconds())
+	code := "error"
+	if err == nil {
+		code = strconv.Itoa(resp.StatusCode)
+	}
+	kubeRequests.WithLabelValues(verb, resource, code).Inc()
+	return resp, err
+}
+
+// kubeRequestInfo derives the verb and the resource type of a Kubernetes
+// API request from its method and path, which is one of
+//
+//	/api/v1[/namespaces/{namespace}]/{resource}[/{name}[/{subresource}]]
+//	/apis/{group}/{version}[/namespaces/{namespace}]/{resource}[/{name}[/{subresource}]]
+//
+// Discovery requests are reported as resource "discovery" and other paths
+// as "other".
+func kubeRequestInfo(req *http.Request) (verb, resource string) {
+	parts := strings.Split(strings.Trim(req.URL.Path, "/"), "/")
+	switch {
+	case len(parts) >= 3 && parts[0] == "api":
+		parts = parts[2:]
+	case len(parts) >= 4 && parts[0] == "apis":
+		resource = parts[1] + "/"
+		parts = parts[3:]
+	case parts[0] == "api" || parts[0] == "apis":
+		return strings.ToLower(req.Method), "discovery"
+	default:
+		return strings.ToLower(req.Method), "other"
+	}
+	// The namespaces resource itself is /namespaces[/{name}], anything
+	// longer is a namespaced resource.
+	if len(parts) >= 3 && parts[0] == "namespaces" {
+		parts = parts[2:]
+	}
+	if len(parts) == 0 {
+		return strings.ToLower(req.Method), "other"
+	}
+	resource += parts[0]
+	if len(parts) >= 3 {
+		resource += "/" + parts[2]
+	}
+	named := len(parts) >= 2
+	switch req.Method {
+	case http.MethodGet:
+		switch {
+		case req.URL.Query().Get("watch") == "true":
+			verb = "watch"
+		case named:
+			verb = "get"
+		default:
+			verb = "list"
+		}
+	case http.MethodPost:
+		verb = "create"
+	case http.MethodPut:
+		verb = "update"
+	case http.MethodPatch:
+		verb = "patch"
+	case http.MethodDelete:
+		if named {
+			verb = "delete"
+		} else {
+			verb = "deletecollection"
+		}
+	default:
+		verb = strings.ToLower(req.Method)
+	}
+	return verb, resource
+}
@@ -1,24 +1,268 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"flag"
 	"fmt"
-	"log"
+	"log/slog"
 	"mcp-db/internal/api"
+	"mcp-db/internal/audit"
+	"mcp-db/internal/auth"
+	"mcp-db/internal/k8s"
+	"mcp-db/internal/logging"
 	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
 )
 
 func main() {
 	var port string
+	var roleRules string
+	var engineFile string
+	var stdio bool
+	var kubeconfigStore string
+	var inCluster bool
+	var clientTTL time.Duration
+	var maxClients int
+	var apiKeys, jwks, jwtIssuer, jwtAudience, policyFile string
+	var tokenReview bool
+	var tokenAudience string
+	var auditFile, auditWebhook string
+	var auditMaxSize, auditMaxBackups, auditRecent int
+	var logLevel string
+	var readTimeout, writeTimeout, idleTimeout, drainDelay, shutdownTimeout time.Duration
 	flag.StringVar(&port, "port", "8080", "Port for the API server")
+	flag.StringVar(&roleRules, "role-rules", "", "YAML or JSON file overriding the Role rules per database type")
+	flag.StringVar(&engineFile, "engines", "", "YAML or JSON engine registry file, reloaded on change")
+	flag.StringVar(&kubeconfigStore, "kubeconfig-store", "memory", "Where registered kubeconfigs are kept: memory or secret")
+	flag.BoolVar(&inCluster, "in-cluster", false, "Serve requests without a kubeconfig with the service account, impersonating the caller authenticated by TokenReview")
+	flag.DurationVar(&clientTTL, "client-cache-ttl", k8s.DefaultClientTTL, "How long an unused Kubernetes client stays cached")
+	flag.IntVar(&maxClients, "client-cache-size", k8s.DefaultMaxClients, "Maximum number of cached Kubernetes clients; the least recently used is evicted")
+	flag.StringVar(&apiKeys, "api-keys", "", "YAML or JSON file of the static API keys accepted as X-API-Key or bearer tokens")
+	flag.StringVar(&jwks, "jwks", "", "JWKS file of the keys that sign accepted bearer JWTs")
+	flag.StringVar(&jwtIssuer, "jwt-issuer", "", "Issuer required in bearer JWTs")
+	flag.StringVar(&jwtAudience, "jwt-audience", "", "Audience required in bearer JWTs")
+	flag.BoolVar(&tokenReview, "token-review", false, "Accept Kubernetes bearer tokens validated by TokenReview")
+	flag.StringVar(&tokenAudience, "token-audience", auth.DefaultTokenAudience, "Comma-separated audiences Kubernetes tokens must be issued for; empty accepts tokens of the API server")
+	flag.StringVar(&policyFile, "auth-policy", "", "YAML or JSON file mapping callers to the operations and namespaces they may use")
+	flag.StringVar(&auditFile, "audit-file", "", "File the audit events are appended to as JSON lines")
+	flag.IntVar(&auditMaxSize, "audit-max-size", audit.DefaultMaxFileSize>>20, "Size in MiB at which the audit file is rotated")
+	flag.IntVar(&auditMaxBackups, "audit-max-backups", audit.DefaultMaxBackups, "Number of rotated audit files kept")
+	flag.StringVar(&auditWebhook, "audit-webhook", "", "URL every audit event is posted to")
+	flag.IntVar(&auditRecent, "audit-recent", audit.DefaultRecent, "Number of recent audit events kept for /audit/events")
+	flag.StringVar(&logLevel, "log-level", "info", "Minimum level of logged messages: debug, info, warn or error")
+	flag.DurationVar(&readTimeout, "read-timeout", api.DefaultReadTimeout, "Maximum duration of reading a request")
+	flag.DurationVar(&writeTimeout, "write-timeout", api.DefaultWriteTimeout, "Maximum duration of handling a request and writing its response")
+	flag.DurationVar(&idleTimeout, "idle-timeout", api.DefaultIdleTimeout, "How long an idle keep-alive connection stays open")
+	flag.DurationVar(&drainDelay, "drain-delay", api.DefaultDrainDelay, "How long to keep serving on SIGTERM after failing the readiness check")
+	flag.DurationVar(&shutdownTimeout, "shutdown-timeout", 25*time.Second, "How long requests in flight may take to complete on SIGTERM")
+	flag.BoolVar(&stdio, "stdio", false, "Serve MCP over stdin and stdout instead of HTTP")
 	flag.Parse()
+	if envLevel := os.Getenv("LOG_LEVEL"); envLevel != "" {
+		logLevel = envLevel
+	}
+	level, err := logging.ParseLevel(logLevel)
+	if err != nil {
+		fatal(err)
+	}
+	slog.SetDefault(logging.New(os.Stderr, level))
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
 	if envPort := os.Getenv("SERVER_PORT"); envPort != "" {
 		port = envPort
 	}
+	if envRules := os.Getenv("ROLE_RULES_FILE"); envRules != "" {
+		roleRules = envRules
+	}
+	if envEngines := os.Getenv("ENGINES_FILE"); envEngines != "" {
+		engineFile = envEngines
+	}
+	if envStore := os.Getenv("KUBECONFIG_STORE"); envStore != "" {
+		kubeconfigStore = envStore
+	}
+	if os.Getenv("IN_CLUSTER") == "true" {
+		inCluster = true
+	}
+	if envKeys := os.Getenv("API_KEYS_FILE"); envKeys != "" {
+		apiKeys = envKeys
+	}
+	if envJWKS := os.Getenv("JWKS_FILE"); envJWKS != "" {
+		jwks = envJWKS
+	}
+	if envIssuer := os.Getenv("JWT_ISSUER"); envIssuer != "" {
+		jwtIssuer = envIssuer
+	}
+	if envAudience := os.Getenv("JWT_AUDIENCE"); envAudience != "" {
+		jwtAudience = envAudience
+	}
+	if os.Getenv("TOKEN_REVIEW") == "true" {
+		tokenReview = true
+	}
+	if envAudience, ok := os.LookupEnv("TOKEN_AUDIENCE"); ok {
+		tokenAudience = envAudience
+	}
+	if envPolicy := os.Getenv("AUTH_POLICY_FILE"); envPolicy != "" {
+		policyFile = envPolicy
+	}
+	if engineFile != "" {
+		if err := k8s.LoadEngineFile(engineFile); err != nil {
+			fatal(err)
+		}
+		go k8s.WatchEngineFile(ctx, 10*time.Second)
+	}
+	if roleRules != "" {
+		if err := k8s.LoadRoleRules(roleRules); err != nil {
+			fatal(err)
+		}
+	}
+	if envAudit := os.Getenv("AUDIT_FILE"); envAudit != "" {
+		auditFile = envAudit
+	}
+	if envWebhook := os.Getenv("AUDIT_WEBHOOK_URL"); envWebhook != "" {
+		auditWebhook = envWebhook
+	}
+	if err := k8s.ValidateDatabaseConfigs(); err != nil {
+		fatal(err)
+	}
+	if err := api.ValidateOpenAPI(); err != nil {
+		fatal(err)
+	}
+	store, err := newKubeconfigStore(kubeconfigStore, os.Getenv("KUBECONFIG_STORE_KEY"))
+	if err != nil {
+		fatal(err)
+	}
+	var sinks []audit.Sink
+	if auditFile != "" {
+		sink, err := audit.NewFileSink(auditFile, int64(auditMaxSize)<<20, auditMaxBackups)
+		if err != nil {
+			fatal(err)
+		}
+		sinks = append(sinks, sink)
+	}
+	if auditWebhook != "" {
+		sinks = append(sinks, audit.NewWebhookSink(auditWebhook))
+	}
+	auditLog := audit.NewLogger(auditRecent, sinks...)
+	defer auditLog.Close()
+	opts := api.Options{
+		Kubeconfigs:     store,
+		ClientTTL:       clientTTL,
+		MaxClients:      maxClients,
+		AuditLog:        auditLog,
+		ReadTimeout:     readTimeout,
+		WriteTimeout:    writeTimeout,
+		IdleTimeout:     idleTimeout,
+		DrainDelay:      drainDelay,
+		ReadinessChecks: map[string]api.ReadinessCheck{},
+	}
+	if pinger, ok := store.(interface{ Ping(context.Context) error }); ok {
+		opts.ReadinessChecks["kubeconfig_store"] = pinger.Ping
+	}
+	var authenticators []auth.Authenticator
+	if apiKeys != "" {
+		authenticator, err := auth.LoadAPIKeys(apiKeys)
+		if err != nil {
+			fatal(err)
+		}
+		authenticators = append(authenticators, authenticator)
+	}
+	if jwks != "" {
+		authenticator, err := auth.NewJWTAuthenticator(jwks, jwtIssuer, jwtAudience)
+		if err != nil {
+			fatal(err)
+		}
+		authenticators = append(authenticators, authenticator)
+	}
+	// Configuring an authenticator makes authentication mandatory. In-cluster
+	// mode alone still serves callers that bring their own kubeconfig.
+	opts.RequireAuth = len(authenticators) > 0 || tokenReview
+	if inCluster || tokenReview {
+		client, err := k8s.NewInClusterClient()
+		if err != nil {
+			fatal(fmt.Errorf("in-cluster mode and TokenReview must run in a cluster: %w", err))
+		}
+		if inCluster {
+			opts.InClusterConfig = client.Config()
+		}
+		authenticators = append(authenticators, auth.NewTokenReviewAuthenticator(client.ClientSet, splitList(tokenAudience)))
+		opts.ReadinessChecks["kubernetes"] = client.Ping
+	}
+	if len(authenticators) > 0 {
+		opts.Authenticator = auth.Chain(authenticators...)
+	}
+	if policyFile != "" {
+		if opts.Authenticator == nil {
+			fatal(errors.New("an authorization policy needs -api-keys, -jwks, -token-review or -in-cluster to identify callers"))
+		}
+		policy, err := auth.LoadPolicy(policyFile)
+		if err != nil {
+			fatal(err)
+		}
+		// MCP over stdio serves the local user who started the process.
+		if !stdio {
+			opts.Policy = policy
+		}
+	}
 	addr := fmt.Sprintf(":%s", port)
-	server := api.NewServer(addr)
-	log.Printf("Starting server on %s...", addr)
-	if err := server.Start(); err != nil {
-		log.Fatal(err)
+	server := api.NewServer(addr, opts)
+	if stdio {
+		if err := server.MCP().ServeStdio(ctx, os.Stdin, os.Stdout); err != nil {
+			fatal(err)
+		}
+		return
+	}
+	slog.Info("Starting server", "addr", addr)
+	errs := make(chan error, 1)
+	go func() { errs <- server.Start() }()
+	select {
+	case err := <-errs:
+		if err != nil {
+			fatal(err)
+		}
+	case <-ctx.Done():
+		stop()
+		slog.Info("Shutting down server", "drain_delay", drainDelay.String(), "timeout", shutdownTimeout.String())
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), drainDelay+shutdownTimeout)
+		defer cancel()
+		if err := server.Shutdown(shutdownCtx); err != nil {
+			slog.Error("Failed to drain requests", "error", err)
+		}
+	}
+}
+
+// splitList splits a comma-separated flag value, dropping empty items.
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// fatal logs err and exits.
+func fatal(err error) {
+	slog.Error(err.Error())
+	os.Exit(1)
+}
+
+// newKubeconfigStore builds the kubeconfig store selected by kind. The secret
+// store keeps Secrets in the namespace of the service.
+func newKubeconfigStore(kind, key string) (k8s.KubeconfigStore, error) {
+	switch kind {
+	case "memory":
+		return k8s.NewMemoryKubeconfigStore(key)
+	case "secret":
+		client, err := k8s.NewInClusterClient()
+		if err != nil {
+			return nil, fmt.Errorf("the secret kubeconfig store must run in a cluster: %w", err)
+		}
+		return k8s.NewSecretKubeconfigStore(client.ClientSet, k8s.InClusterNamespace(), key)
+	default:
+		return nil, fmt.Errorf("unknown kubeconfig store %q, use memory or secret", kind)
 	}
 }
@@ -1,29 +1,59 @@
 package types
 
 type CreateDatabaseRequest struct {
-	Name          string `json:"name"`
-	Type          string `json:"type"`
-	Version       string `json:"version,omitempty"`
-	Namespace     string `json:"namespace,omitempty"`
-	CPULimit      string `json:"cpu,omitempty"`
-	MemoryLimit   string `json:"memory,omitempty"`
-	CPURequest    string `json:"cpu_request,omitempty"`
-	MemoryRequest string `json:"memory_request,omitempty"`
-	Storage       string `json:"storage,omitempty"`
-	Kubeconfig    string `json:"kubeconfig,omitempty"`
+	Name              string            `json:"name"`
+	Type              string            `json:"type"`
+	Version           string            `json:"version,omitempty"`
+	Namespace         string            `json:"namespace,omitempty"`
+	CPULimit          string            `json:"cpu,omitempty"`
+	MemoryLimit       string            `json:"memory,omitempty"`
+	CPURequest        string            `json:"cpu_request,omitempty"`
+	MemoryRequest     string            `json:"memory_request,omitempty"`
+	Storage           string            `json:"storage,omitempty"`
+	TerminationPolicy string            `json:"termination_policy,omitempty"`
+	Topology          string            `json:"topology,omitempty"`
+	Replicas          int               `json:"replicas,omitempty"`
+	Shards            int               `json:"shards,omitempty"`
+	NodeLabels        map[string]string `json:"node_labels,omitempty"`
+	Tolerations       []Toleration      `json:"tolerations,omitempty"`
+	PodAntiAffinity   string            `json:"pod_anti_affinity,omitempty"`
+	Tenancy           string            `json:"tenancy,omitempty"`
+	TopologyKeys      []string          `json:"topology_keys,omitempty"`
+	StorageClass      string            `json:"storage_class,omitempty"`
+	Volumes           []VolumeRequest   `json:"volumes,omitempty"`
+	Kubeconfig        string            `json:"kubeconfig,omitempty"`
+	KubeconfigRef     string            `json:"kubeconfig_ref,omitempty"`
+}
+
+type Toleration struct {
+	Key               string `json:"key,omitempty"`
+	Operator          string `json:"operator,omitempty"`
+	Value             string `json:"value,omitempty"`
+	Effect            string `json:"effect,omitempty"`
+	TolerationSeconds *int64 `json:"toleration_seconds,omitempty"`
 }
 
 type ListDatabasesRequest struct {
-	Namespace  string `json:"namespace,omitempty"`
-	Type       string `json:"type,omitempty"`
-	Kubeconfig string `json:"kubeconfig,omitempty"`
+	Namespace     string `json:"namespace,omitempty"`
+	Type          string `json:"type,omitempty"`
+	Kubeconfig    string `json:"kubeconfig,omitempty"`
+	KubeconfigRef string `json:"kubeconfig_ref,omitempty"`
 }
 
 type DeleteDatabaseRequest struct {
-	Name       string `json:"name"`
-	Namespace  string `json:"namespace,omitempty"`
-	Token      string `json:"token,omitempty"`
-	Kubeconfig string `json:"kubeconfig,omitempty"`
+	Name          string `json:"name"`
+	Namespace     string `json:"namespace,omitempty"`
+	Confirm       string `json:"confirm,omitempty"`
+	Kubeconfig    string `json:"kubeconfig,omitempty"`
+	KubeconfigRef string `json:"kubeconfig_ref,omitempty"`
+}
+
+type UpdateDatabaseRequest struct {
+	Name              string `json:"name"`
+	Namespace         string `json:"namespace,omitempty"`
+	TerminationPolicy string `json:"termination_policy,omitempty"`
+	Kubeconfig        string `json:"kubeconfig,omitempty"`
+	KubeconfigRef     string `json:"kubeconfig_ref,omitempty"`
 }
 
 type Response struct {
@@ -33,31 +63,166 @@ type Response struct {
 }
 
 type DBClusterInfo struct {
-	Name           string `json:"name"`
-	Type           string `json:"type"`
-	Version        string `json:"version"`
-	Status         string `json:"status"`
-	CreatedAt      string `json:"created_at"`
-	CPULimit       string `json:"cpu_limit,omitempty"`
-	MemoryLimit    string `json:"memory_limit,omitempty"`
-	CPURequest     string `json:"cpu_request,omitempty"`
-	MemoryRequest  string `json:"memory_request,omitempty"`
-	Storage        string `json:"storage,omitempty"`
-	AccessMode     string `json:"access_mode,omitempty"`
-	Replicas       int64  `json:"replicas,omitempty"`
-	ServiceAccount string `json:"service_account,omitempty"`
+	Name              string       `json:"name"`
+	Type              string       `json:"type"`
+	Version           string       `json:"version"`
+	Status            string       `json:"status"`
+	CreatedAt         string       `json:"created_at"`
+	CPULimit          string       `json:"cpu_limit,omitempty"`
+	MemoryLimit       string       `json:"memory_limit,omitempty"`
+	CPURequest        string       `json:"cpu_request,omitempty"`
+	MemoryRequest     string       `json:"memory_request,omitempty"`
+	Storage           string       `json:"storage,omitempty"`
+	AccessMode        string       `json:"access_mode,omitempty"`
+	Replicas          int64        `json:"replicas,omitempty"`
+	ServiceAccount    string       `json:"service_account,omitempty"`
+	TerminationPolicy string       `json:"termination_policy,omitempty"`
+	APIVersion        string       `json:"api_version,omitempty"`
+	Topology          string       `json:"topology,omitempty"`
+	Shards            int64        `json:"shards,omitempty"`
+	Pods              []PodInfo    `json:"pods,omitempty"`
+	StorageClass      string       `json:"storage_class,omitempty"`
+	Volumes           []VolumeInfo `json:"volumes,omitempty"`
+}
+
+type VolumeRequest struct {
+	Name    string `json:"name"`
+	Storage string `json:"storage"`
+}
+
+type VolumeInfo struct {
+	Name         string `json:"name"`
+	Volume       string `json:"volume,omitempty"`
+	Component    string `json:"component,omitempty"`
+	Capacity     string `json:"capacity,omitempty"`
+	StorageClass string `json:"storage_class,omitempty"`
+	Status       string `json:"status"`
+}
+
+type PodInfo struct {
+	Name      string `json:"name"`
+	Component string `json:"component,omitempty"`
+	Role      string `json:"role,omitempty"`
+	Status    string `json:"status"`
 }
 
 type GetDatabasesRequest struct {
-	Namespace  string `json:"namespace,omitempty"`
-	Name       string `json:"name,omitempty"`
-	Kubeconfig string `json:"kubeconfig,omitempty"`
+	Namespace     string `json:"namespace,omitempty"`
+	Name          string `json:"name,omitempty"`
+	Kubeconfig    string `json:"kubeconfig,omitempty"`
+	KubeconfigRef string `json:"kubeconfig_ref,omitempty"`
 }
 
 type DatabasesResponse struct {
-	Dsn      string `json:"dsn"`
-	Address  string `json:"address"`
-	Port     string `json:"port"`
-	Username string `json:"username"`
-	Password string `json:"password"`
+	Type        string          `json:"type"`
+	Dsn         string          `json:"dsn"`
+	Address     string          `json:"address"`
+	Port        string          `json:"port"`
+	Username    string          `json:"username"`
+	Password    string          `json:"password"`
+	Database    string          `json:"database,omitempty"`
+	JdbcUrl     string          `json:"jdbc_url,omitempty"`
+	Cli         string          `json:"cli,omitempty"`
+	ExternalDsn string          `json:"external_dsn,omitempty"`
+	External    *ExternalAccess `json:"external,omitempty"`
+}
+
+type ExposeDatabaseRequest struct {
+	Name          string `json:"name"`
+	Namespace     string `json:"namespace"`
+	Enable        bool   `json:"enable"`
+	ServiceType   string `json:"service_type,omitempty"`
+	Kubeconfig    string `json:"kubeconfig,omitempty"`
+	KubeconfigRef string `json:"kubeconfig_ref,omitempty"`
+}
+
+type ExternalAccess struct {
+	ServiceType string `json:"service_type"`
+	Service     string `json:"service"`
+	Host        string `json:"host,omitempty"`
+	Port        string `json:"port,omitempty"`
+	Status      string `json:"status"`
+	JdbcUrl     string `json:"jdbc_url,omitempty"`
+	Cli         string `json:"cli,omitempty"`
+}
+
+type GarbageReport struct {
+	Namespace       string   `json:"namespace"`
+	ServiceAccounts []string `json:"service_accounts"`
+	Roles           []string `json:"roles"`
+	RoleBindings    []string `json:"role_bindings"`
+}
+
+type FieldDiff struct {
+	Field     string `json:"field"`
+	Existing  string `json:"existing"`
+	Requested string `json:"requested"`
+}
+
+type ListEnginesRequest struct {
+	Kubeconfig    string `json:"kubeconfig,omitempty"`
+	KubeconfigRef string `json:"kubeconfig_ref,omitempty"`
+}
+
+type EngineInfo struct {
+	Type            string   `json:"type,omitempty"`
+	Definition      string   `json:"definition"`
+	APIVersion      string   `json:"api_version"`
+	Supported       bool     `json:"supported"`
+	DefaultVersion  string   `json:"default_version,omitempty"`
+	Versions        []string `json:"versions"`
+	ClusterVersions []string `json:"cluster_versions"`
+}
+
+type RotateCredentialsRequest struct {
+	Name          string `json:"name"`
+	Namespace     string `json:"namespace"`
+	Kubeconfig    string `json:"kubeconfig,omitempty"`
+	KubeconfigRef string `json:"kubeconfig_ref,omitempty"`
+}
+
+type RotateCredentialsResponse struct {
+	Name                string   `json:"name"`
+	Username            string   `json:"username"`
+	RotatedAt           string   `json:"rotated_at"`
+	RestartedComponents []string `json:"restarted_components,omitempty"`
+	OpsRequest          string   `json:"ops_request,omitempty"`
+	Warning             string   `json:"warning,omitempty"`
+}
+
+type RegisterKubeconfigRequest struct {
+	Kubeconfig string `json:"kubeconfig"`
+	Context    string `json:"context,omitempty"`
+	TTLSeconds int    `json:"ttl_seconds,omitempty"`
+}
+
+type KubeconfigHandle struct {
+	Handle    string `json:"handle"`
+	ExpiresAt string `json:"expires_at"`
+}
+
+type RevokeKubeconfigRequest struct {
+	Handle string `json:"handle"`
+}
+
+type AuditEvent struct {
+	Time       string                 `json:"time"`
+	Operation  string                 `json:"operation"`
+	Identity   string                 `json:"identity,omitempty"`
+	Groups     []string               `json:"groups,omitempty"`
+	Target     string                 `json:"target,omitempty"`
+	Params     map[string]interface{} `json:"params,omitempty"`
+	Outcome    string                 `json:"outcome"`
+	Status     int                    `json:"status"`
+	Error      string                 `json:"error,omitempty"`
+	DurationMs int64                  `json:"duration_ms"`
+}
+
+type ListAuditEventsRequest struct {
+	Operation string `json:"operation,omitempty"`
+	Identity  string `json:"identity,omitempty"`
+	Target    string `json:"target,omitempty"`
+	Outcome   string `json:"outcome,omitempty"`
+	Since     string `json:"since,omitempty"`
+	Limit     int    `json:"limit,omitempty"`
 }
@@ -0,0 +1,142 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"manageDatabase/internal/audit"
+	"manageDatabase/internal/auth"
+	"manageDatabase/internal/database"
+	"manageDatabase/internal/logging"
+	"manageDatabase/pkg/types"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// auditedOperations change a server or run SQL on it.
+var auditedOperations = map[string]bool{
+	"create_database": true,
+	"delete_database": true,
+	"exec_sql":        true,
+}
+
+// maxAuditedError bounds the response body kept to report a failure.
+const maxAuditedError = 4096
+
+// auditRecorder captures the status of a response, and its body when it
+// is an error.
+type auditRecorder struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (r *auditRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *auditRecorder) Write(data []byte) (int, error) {
+	if r.status >= http.StatusBadRequest && r.body.Len() < maxAuditedError {
+		r.body.Write(data[:min(len(data), maxAuditedError-r.body.Len())])
+	}
+	return r.ResponseWriter.Write(data)
+}
+
+// audit wraps the handler of an audited operation to record an event with
+// the caller, the target server and database, the redacted parameters, the
+// outcome and the duration. Passwords in the dsn, the SQL statement and the
+// error are redacted before the event is recorded. Other operations are returned unchanged.
+func (s *Server) audit(operation string, handler http.HandlerFunc) http.HandlerFunc {
+	if s.auditLog == nil || !auditedOperations[operation] {
+		return handler
+	}
+	return func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			http.Error(w, "Invalid request format", http.StatusBadRequest)
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+		recorder := &auditRecorder{ResponseWriter: w, status: http.StatusOK}
+		handler(recorder, r)
+
+		event := types.AuditEvent{
+			Time:       start.UTC().Format(time.RFC3339Nano),
+			Operation:  operation,
+			Status:     recorder.status,
+			DurationMs: time.Since(start).Milliseconds(),
+		}
+		if identity, ok := auth.IdentityFrom(r.Context()); ok {
+			event.Identity, event.Groups = identity.Name, identity.Groups
+		}
+		var params map[string]interface{}
+		if json.Unmarshal(body, &params) == nil {
+			driver, _ := params["type"].(string)
+			dsn, _ := params["dsn"].(string)
+			name, _ := params["name"].(string)
+			event.Target = database.ServerAddress(driver, dsn)
+			if name != "" {
+				event.Target += "/" + name
+			}
+			if dsn != "" {
+				params["dsn"] = database.RedactDSN(driver, dsn)
+			}
+			// Statements such as ALTER USER ... IDENTIFIED BY '...' carry
+			// passwords.
+			if sql, ok := params["sql"].(string); ok {
+				params["sql"] = logging.RedactString(sql)
+			}
+			event.Params = audit.Redact(params)
+		}
+		switch {
+		case recorder.status == http.StatusUnauthorized || recorder.status == http.StatusForbidden:
+			event.Outcome = audit.OutcomeDenied
+		case recorder.status >= http.StatusBadRequest:
+			event.Outcome = audit.OutcomeFailure
+		default:
+			event.Outcome = audit.OutcomeSuccess
+		}
+		if event.Outcome != audit.OutcomeSuccess {
+			event.Error = logging.RedactString(strings.TrimSpace(recorder.body.String()))
+		}
+		s.auditLog.Record(event)
+	}
+}
+
+// ListAuditEventsHandler returns the recent audit events, newest first.
+func (s *Server) ListAuditEventsHandler(w http.ResponseWriter, r *http.Request) {
+	if !onlyAllowPost(w, r) {
+		return
+	}
+
+	var req types.ListAuditEventsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request format", http.StatusBadRequest)
+		return
+	}
+	filter := audit.Filter{
+		Operation: req.Operation,
+		Identity:  req.Identity,
+		Target:    req.Target,
+		Outcome:   req.Outcome,
+		Limit:     req.Limit,
+	}
+	if req.Since != "" {
+		since, err := time.Parse(time.RFC3339, req.Since)
+		if err != nil {
+			http.Error(w, "since must be an RFC 3339 time", http.StatusBadRequest)
+			return
+		}
+		filter.Since = since
+	}
+	events := []types.AuditEvent{}
+	if s.auditLog != nil {
+		events = s.auditLog.Recent(filter)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(events)
+}
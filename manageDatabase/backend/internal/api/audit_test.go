@@ -0,0 +1,39 @@
+package api
+
+import (
+	"manageDatabase/internal/audit"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestAuditRedactsSQLPasswords(t *testing.T) {
+	s := &Server{auditLog: audit.NewLogger(10)}
+	failing := func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "failed to execute SQL: syntax error near IDENTIFIED BY 'hunter2'", http.StatusInternalServerError)
+	}
+	body := `{"type": "mysql", "dsn": "root:rootpw@tcp(db:3306)/", "sql": "ALTER USER app IDENTIFIED BY 'hunter2'; CREATE USER ops PASSWORD 's3cret'"}`
+	s.audit("exec_sql", failing)(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/databases/exec", strings.NewReader(body)))
+
+	events := s.auditLog.Recent(audit.Filter{})
+	if len(events) != 1 {
+		t.Fatalf("recorded %d events, want 1", len(events))
+	}
+	event := events[0]
+	sql, _ := event.Params["sql"].(string)
+	for _, secret := range []string{"hunter2", "s3cret"} {
+		if strings.Contains(sql, secret) {
+			t.Errorf("sql parameter %q contains the password %q", sql, secret)
+		}
+		if strings.Contains(event.Error, secret) {
+			t.Errorf("error %q contains the password %q", event.Error, secret)
+		}
+	}
+	if dsn, _ := event.Params["dsn"].(string); strings.Contains(dsn, "rootpw") {
+		t.Errorf("dsn parameter %q contains the password", dsn)
+	}
+	if !strings.Contains(sql, "ALTER USER app IDENTIFIED BY") {
+		t.Errorf("sql parameter %q lost the statement", sql)
+	}
+}
@@ -0,0 +1,56 @@
+package api
+
+import (
+	"fmt"
+	"log/slog"
+	"manageDatabase/internal/auth"
+	"net/http"
+	"slices"
+)
+
+// explicitOperations expose the requests of every caller. They are only
+// allowed to callers the policy grants them to by name.
+var explicitOperations = []string{"list_audit_events"}
+
+// authorize wraps the handler of operation with the authorization policy.
+// Without a policy every request is allowed, except for the
+// explicitOperations.
+func (s *Server) authorize(operation string, handler http.HandlerFunc) http.HandlerFunc {
+	if slices.Contains(explicitOperations, operation) {
+		return s.authorizeExplicit(operation, handler)
+	}
+	if s.policy == nil {
+		return handler
+	}
+	return func(w http.ResponseWriter, r *http.Request) {
+		identity, ok := auth.IdentityFrom(r.Context())
+		if !ok {
+			auth.Unauthorized(w, "Authentication required")
+			return
+		}
+		if !s.policy.Allows(identity, operation) {
+			slog.WarnContext(r.Context(), "Denied operation", "operation", operation, "identity", identity.Name)
+			http.Error(w, fmt.Sprintf("%s may not %s", identity.Name, operation), http.StatusForbidden)
+			return
+		}
+		handler(w, r)
+	}
+}
+
+// authorizeExplicit allows operation only to callers a policy rule names it
+// for; a wildcard rule or running without a policy is not enough.
+func (s *Server) authorizeExplicit(operation string, handler http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		identity, ok := auth.IdentityFrom(r.Context())
+		if !ok {
+			auth.Unauthorized(w, "Authentication required")
+			return
+		}
+		if s.policy == nil || !s.policy.Grants(identity, operation) {
+			slog.WarnContext(r.Context(), "Denied operation", "operation", operation, "identity", identity.Name)
+			http.Error(w, fmt.Sprintf("%s may not %s: the authorization policy must grant it by name", identity.Name, operation), http.StatusForbidden)
+			return
+		}
+		handler(w, r)
+	}
+}
@@ -0,0 +1,41 @@
+package api
+
+import (
+	"manageDatabase/internal/auth"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestAuthorizeAuditEventsNeedsExplicitGrant(t *testing.T) {
+	alice := &auth.Identity{Name: "alice"}
+	tests := []struct {
+		name     string
+		policy   *auth.Policy
+		identity *auth.Identity
+		want     int
+	}{
+		{"no policy", nil, alice, http.StatusForbidden},
+		{"no identity", nil, nil, http.StatusUnauthorized},
+		{"wildcard rule", &auth.Policy{Rules: []auth.PolicyRule{{Users: []string{"alice"}, Operations: []string{auth.Wildcard}}}}, alice, http.StatusForbidden},
+		{"other user", &auth.Policy{Rules: []auth.PolicyRule{{Users: []string{"bob"}, Operations: []string{"list_audit_events"}}}}, alice, http.StatusForbidden},
+		{"explicit grant", &auth.Policy{Rules: []auth.PolicyRule{{Users: []string{"alice"}, Operations: []string{"list_audit_events"}}}}, alice, http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &Server{policy: tt.policy}
+			handler := s.authorize("list_audit_events", func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusOK)
+			})
+			r := httptest.NewRequest(http.MethodPost, "/audit/events", nil)
+			if tt.identity != nil {
+				r = r.WithContext(auth.WithIdentity(r.Context(), tt.identity))
+			}
+			w := httptest.NewRecorder()
+			handler(w, r)
+			if w.Code != tt.want {
+				t.Errorf("status = %d, want %d", w.Code, tt.want)
+			}
+		})
+	}
+}
@@ -3,6 +3,7 @@ package api
 import (
 	"encoding/json"
 	"fmt"
+	"log/slog"
 	"manageDatabase/internal/database"
 	"manageDatabase/pkg/types"
 	"net/http"
@@ -17,13 +18,13 @@ func (s *Server) CreateDatabaseHandler(w http.ResponseWriter, r *http.Request) {
 	var req types.CreateDatabaseRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		http.Error(w, "Invalid request format", http.StatusBadRequest)
-		fmt.Println("Decode error:", err)
+		slog.WarnContext(r.Context(), "Failed to decode request", "error", err)
 		return
 	}
 
-	if err := database.CreateDatabase(req.Type, req.DSN, req.Name); err != nil {
+	if err := database.CreateDatabase(r.Context(), req.Type, req.DSN, req.Name); err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
-		fmt.Println("CreateDatabase error:", err)
+		slog.ErrorContext(r.Context(), "Failed to create database", "type", req.Type, "server", database.ServerAddress(req.Type, req.DSN), "name", req.Name, "error", err)
 		return
 	}
 
@@ -40,14 +41,14 @@ func (s *Server) ListDatabasesHandler(w http.ResponseWriter, r *http.Request) {
 	var req types.ListDatabaseRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		http.Error(w, "Invalid request format", http.StatusBadRequest)
-		fmt.Println("Decode error:", err)
+		slog.WarnContext(r.Context(), "Failed to decode request", "error", err)
 		return
 	}
 
-	databases, err := database.ListDatabases(req.Type, req.DSN)
+	databases, err := database.ListDatabases(r.Context(), req.Type, req.DSN)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
-		fmt.Println("ListDatabases error:", err)
+		slog.ErrorContext(r.Context(), "Failed to list databases", "type", req.Type, "server", database.ServerAddress(req.Type, req.DSN), "error", err)
 		return
 	}
 
@@ -73,13 +74,13 @@ func (s *Server) DeleteDatabaseHandler(w http.ResponseWriter, r *http.Request) {
 	var req types.DeleteDatabaseRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		http.Error(w, "Invalid request format", http.StatusBadRequest)
-		fmt.Println("Decode error:", err)
+		slog.WarnContext(r.Context(), "Failed to decode request", "error", err)
 		return
 	}
 
-	if err := database.DeleteDatabase(req.Type, req.DSN, req.Name); err != nil {
+	if err := database.DeleteDatabase(r.Context(), req.Type, req.DSN, req.Name); err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
-		fmt.Println("DeleteDatabase error:", err)
+		slog.ErrorContext(r.Context(), "Failed to delete database", "type", req.Type, "server", database.ServerAddress(req.Type, req.DSN), "name", req.Name, "error", err)
 		return
 	}
 
@@ -96,14 +97,14 @@ func (s *Server) ExecSQLHandler(w http.ResponseWriter, r *http.Request) {
 	var req types.ExecSQLRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		http.Error(w, "Invalid request format", http.StatusBadRequest)
-		fmt.Println("Decode error:", err)
+		slog.WarnContext(r.Context(), "Failed to decode request", "error", err)
 		return
 	}
 
-	result, err := database.ExecSQL(req.Type, req.DSN, req.SQL)
+	result, err := database.ExecSQL(r.Context(), req.Type, req.DSN, req.SQL)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
-		fmt.Println("ExecSQL error:", err)
+		slog.ErrorContext(r.Context(), "Failed to execute SQL", "type", req.Type, "server", database.ServerAddress(req.Type, req.DSN), "sql", req.SQL, "error", err)
 		return
 	}
 
@@ -0,0 +1,36 @@
+package api
+
+import (
+	"manageDatabase/internal/mcp"
+)
+
+// MCPServerName and MCPServerVersion identify this service to MCP clients.
+const (
+	MCPServerName    = "manage-database"
+	MCPServerVersion = "1.0.0"
+)
+
+// MCP returns the MCP server exposing every handler of s as a tool.
+func (s *Server) MCP() *mcp.Server {
+	return mcp.NewServer(MCPServerName, MCPServerVersion, s.tools())
+}
+
+// tools exposes every route over MCP, with the input schemas of the OpenAPI
+// specification. Tool names are the operations of the authorization policy.
+func (s *Server) tools() []mcp.Tool {
+	routes := s.routes()
+	tools := make([]mcp.Tool, 0, len(routes))
+	for _, rt := range routes {
+		tool := mcp.Tool{
+			Name:        rt.Operation,
+			Description: rt.Description,
+			Request:     rt.Request,
+			Handler:     s.audit(rt.Operation, s.authorize(rt.Operation, rt.Handler)),
+		}
+		if rt.Request != nil {
+			tool.InputSchema = requestSchema(rt.Request)
+		}
+		tools = append(tools, tool)
+	}
+	return tools
+}
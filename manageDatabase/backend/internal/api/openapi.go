@@ -0,0 +1,239 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"manageDatabase/internal/audit"
+	"manageDatabase/internal/auth"
+	"manageDatabase/internal/database"
+	"manageDatabase/internal/mcp"
+	"manageDatabase/pkg/types"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// OpenAPIVersion is the version of the API described by /openapi.json.
+const OpenAPIVersion = "1.0.0"
+
+// fieldDoc documents a request field in the OpenAPI specification. Enum is
+// a function so values from the engine registry follow reloads.
+type fieldDoc struct {
+	Description string
+	Enum        func() []string
+	Default     interface{}
+}
+
+// commonFieldDocs document fields shared by most requests.
+var commonFieldDocs = map[string]fieldDoc{
+	"type": {Description: "Database driver", Enum: drivers},
+	"dsn":  {Description: "Data source name of the server, in the format of the driver"},
+	"name": {Description: "Name of the database"},
+}
+
+// fieldDocs document request fields per type, overriding commonFieldDocs.
+// ValidateOpenAPI checks that they match the structs in pkg/types.
+var fieldDocs = map[reflect.Type]map[string]fieldDoc{
+	reflect.TypeOf(types.CreateDatabaseRequest{}): {},
+	reflect.TypeOf(types.ListDatabaseRequest{}):   {},
+	reflect.TypeOf(types.DeleteDatabaseRequest{}): {},
+	reflect.TypeOf(types.ListAuditEventsRequest{}): {
+		"operation": {Description: "Only list events of this operation"},
+		"identity":  {Description: "Only list events of this caller"},
+		"target":    {Description: "Only list events on this target, host:port/database"},
+		"outcome":   {Description: "Only list events with this outcome", Enum: constant(audit.OutcomeSuccess, audit.OutcomeFailure, audit.OutcomeDenied)},
+		"since":     {Description: "Only list events at or after this RFC 3339 time"},
+		"limit":     {Description: "Maximum number of events, all when zero"},
+	},
+	reflect.TypeOf(types.ExecSQLRequest{}): {
+		"sql": {Description: "SQL statement to execute"},
+	},
+}
+
+func drivers() []string {
+	return database.Drivers
+}
+
+func constant(values ...string) func() []string {
+	return func() []string { return values }
+}
+
+// OpenAPI serves the OpenAPI 3 specification of the route table.
+func (s *Server) OpenAPI(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(s.openAPISpec()); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+func (s *Server) openAPISpec() map[string]interface{} {
+	textResponse := func(description string) map[string]interface{} {
+		return map[string]interface{}{
+			"description": description,
+			"content": map[string]interface{}{
+				"text/plain": map[string]interface{}{"schema": map[string]interface{}{"type": "string"}},
+			},
+		}
+	}
+	paths := map[string]interface{}{}
+	for _, rt := range s.routes() {
+		success := textResponse(http.StatusText(http.StatusOK))
+		if rt.Response != nil {
+			success = map[string]interface{}{
+				"description": http.StatusText(http.StatusOK),
+				"content": map[string]interface{}{
+					"application/json": map[string]interface{}{"schema": mcp.SchemaFor(rt.Response)},
+				},
+			}
+		}
+		responses := map[string]interface{}{
+			strconv.Itoa(http.StatusOK): success,
+		}
+		for _, code := range []int{http.StatusBadRequest, http.StatusUnauthorized, http.StatusForbidden, http.StatusMethodNotAllowed, http.StatusInternalServerError} {
+			responses[strconv.Itoa(code)] = textResponse(http.StatusText(code))
+		}
+		paths[rt.Path] = map[string]interface{}{"post": map[string]interface{}{
+			"operationId": strings.ReplaceAll(strings.Trim(rt.Path, "/"), "/", "_"),
+			"summary":     rt.Summary,
+			"requestBody": map[string]interface{}{
+				"required": true,
+				"content": map[string]interface{}{
+					"application/json": map[string]interface{}{"schema": requestSchema(rt.Request)},
+				},
+			},
+			"responses": responses,
+		}}
+	}
+	return map[string]interface{}{
+		"openapi": "3.0.3",
+		"info": map[string]interface{}{
+			"title":   MCPServerName,
+			"version": OpenAPIVersion,
+		},
+		"paths": paths,
+		"components": map[string]interface{}{
+			"securitySchemes": map[string]interface{}{
+				"bearer": map[string]interface{}{"type": "http", "scheme": "bearer"},
+				"apiKey": map[string]interface{}{"type": "apiKey", "in": "header", "name": auth.APIKeyHeader},
+			},
+		},
+		"security": []interface{}{
+			map[string]interface{}{"bearer": []string{}},
+			map[string]interface{}{"apiKey": []string{}},
+		},
+	}
+}
+
+// requestSchema returns the schema of a request struct with the
+// descriptions, enums and defaults of fieldDocs.
+func requestSchema(request interface{}) map[string]interface{} {
+	schema := mcp.SchemaFor(request)
+	annotateSchema(schema, reflect.TypeOf(request))
+	return schema
+}
+
+func annotateSchema(schema map[string]interface{}, t reflect.Type) {
+	for t.Kind() == reflect.Pointer || t.Kind() == reflect.Slice {
+		if t.Kind() == reflect.Slice {
+			schema, _ = schema["items"].(map[string]interface{})
+		}
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct || schema == nil {
+		return
+	}
+	properties, _ := schema["properties"].(map[string]interface{})
+	for name, field := range jsonFields(t) {
+		property, ok := properties[name].(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if doc, ok := lookupFieldDoc(t, name); ok {
+			property["description"] = doc.Description
+			if doc.Enum != nil {
+				property["enum"] = doc.Enum()
+			}
+			if doc.Default != nil {
+				property["default"] = doc.Default
+			}
+		}
+		annotateSchema(property, field.Type)
+	}
+}
+
+func lookupFieldDoc(t reflect.Type, name string) (fieldDoc, bool) {
+	if doc, ok := fieldDocs[t][name]; ok {
+		return doc, true
+	}
+	doc, ok := commonFieldDocs[name]
+	return doc, ok
+}
+
+// jsonFields returns the exported fields of a struct by JSON name.
+func jsonFields(t reflect.Type) map[string]reflect.StructField {
+	fields := map[string]reflect.StructField{}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		fields[name] = field
+	}
+	return fields
+}
+
+// ValidateOpenAPI checks that the OpenAPI field documentation and the
+// request structs agree: every request has documentation, every documented
+// field exists and every request field is documented.
+func ValidateOpenAPI() error {
+	var s Server
+	for _, rt := range s.routes() {
+		if rt.Description == "" {
+			return fmt.Errorf("route %s: operation %q has no MCP tool description", rt.Path, rt.Operation)
+		}
+		if rt.Request == nil {
+			continue
+		}
+		if err := validateFieldDocs(reflect.TypeOf(rt.Request)); err != nil {
+			return fmt.Errorf("route %s: %w", rt.Path, err)
+		}
+	}
+	for t, docs := range fieldDocs {
+		fields := jsonFields(t)
+		for name := range docs {
+			if _, ok := fields[name]; !ok {
+				return fmt.Errorf("documented field %s does not exist in %s", name, t.Name())
+			}
+		}
+	}
+	return nil
+}
+
+func validateFieldDocs(t reflect.Type) error {
+	for t.Kind() == reflect.Pointer || t.Kind() == reflect.Slice {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return nil
+	}
+	if _, ok := fieldDocs[t]; !ok {
+		return fmt.Errorf("%s has no field documentation", t.Name())
+	}
+	for name, field := range jsonFields(t) {
+		if _, ok := lookupFieldDoc(t, name); !ok {
+			return fmt.Errorf("field %s of %s is not documented", name, t.Name())
+		}
+		if err := validateFieldDocs(field.Type); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,74 @@
+package api
+
+import (
+	"manageDatabase/pkg/types"
+	"net/http"
+)
+
+// route is an entry of the route table. The request and response types feed
+// the OpenAPI specification; a nil Response means a plain text message.
+// Operation names the route in authorization policies and is the name of
+// the MCP tool built from it, which Description documents for MCP clients.
+type route struct {
+	Path        string
+	Operation   string
+	Summary     string
+	Description string
+	Handler     http.HandlerFunc
+	Request     interface{}
+	Response    interface{}
+}
+
+// execSQLResponse is the body returned by ExecSQLHandler.
+type execSQLResponse struct {
+	Message string `json:"message"`
+	Result  string `json:"result"`
+}
+
+func (s *Server) routes() []route {
+	return []route{
+		{
+			Path:        "/databases/list",
+			Operation:   "list_databases",
+			Summary:     "List the databases on a server",
+			Description: "List the databases on a MySQL or PostgreSQL server.",
+			Handler:     s.ListDatabasesHandler,
+			Request:     types.ListDatabaseRequest{},
+			Response:    []string{},
+		},
+		{
+			Path:        "/databases/create",
+			Operation:   "create_database",
+			Summary:     "Create a database if it does not exist",
+			Description: "Create a database on a MySQL or PostgreSQL server if it does not exist.",
+			Handler:     s.CreateDatabaseHandler,
+			Request:     types.CreateDatabaseRequest{},
+		},
+		{
+			Path:        "/databases/delete",
+			Operation:   "delete_database",
+			Summary:     "Drop a database",
+			Description: "Drop a database from a MySQL or PostgreSQL server.",
+			Handler:     s.DeleteDatabaseHandler,
+			Request:     types.DeleteDatabaseRequest{},
+		},
+		{
+			Path:        "/databases/exec",
+			Operation:   "exec_sql",
+			Summary:     "Execute a SQL statement",
+			Description: "Execute a SQL statement and report the number of affected rows.",
+			Handler:     s.ExecSQLHandler,
+			Request:     types.ExecSQLRequest{},
+			Response:    execSQLResponse{},
+		},
+		{
+			Path:        "/audit/events",
+			Operation:   "list_audit_events",
+			Summary:     "List the recent audit events, newest first",
+			Description: "List the recent audit events of operations that changed servers or ran SQL, newest first.",
+			Handler:     s.ListAuditEventsHandler,
+			Request:     types.ListAuditEventsRequest{},
+			Response:    []types.AuditEvent{},
+		},
+	}
+}
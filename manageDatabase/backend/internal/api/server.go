@@ -1,33 +1,146 @@
 package api
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"github.com/gorilla/mux"
+	"manageDatabase/internal/audit"
+	"manageDatabase/internal/auth"
+	"manageDatabase/internal/logging"
+	"manageDatabase/internal/metrics"
+	"net"
 	"net/http"
+	"sync/atomic"
+	"time"
 )
 
+// Defaults of the HTTP server timeouts.
+const (
+	DefaultReadTimeout  = 30 * time.Second
+	DefaultWriteTimeout = 2 * time.Minute
+	DefaultIdleTimeout  = 2 * time.Minute
+)
+
+// DefaultDrainDelay is how long Shutdown should keep serving after reporting the
+// server as not ready: two periods of the readiness probe of the
+// deployment, for the endpoint to be removed before connections are
+// refused.
+const DefaultDrainDelay = 10 * time.Second
+
 type Server struct {
-	port   string
-	router *mux.Router
+	router     *mux.Router
+	httpServer *http.Server
+	policy     *auth.Policy
+	auditLog   *audit.Logger
+	readiness  map[string]ReadinessCheck
+	draining   atomic.Bool
+	drainDelay time.Duration
+	cancel     context.CancelFunc
+}
+
+// Options configure a Server.
+type Options struct {
+	// Authenticator identifies callers. When set, every request but those
+	// for the OpenAPI specification and the metrics must authenticate.
+	Authenticator auth.Authenticator
+	// Policy restricts the operations of authenticated callers. Without one
+	// every caller may perform every operation.
+	Policy *auth.Policy
+	// AuditLog records the operations that change servers or run SQL.
+	// Without one nothing is audited.
+	AuditLog *audit.Logger
+	// ReadTimeout, WriteTimeout and IdleTimeout bound the reading of a
+	// request, the writing of its response and idle keep-alive
+	// connections. Zero values take the defaults.
+	ReadTimeout  time.Duration
+	WriteTimeout time.Duration
+	IdleTimeout  time.Duration
+	// DrainDelay is how long Shutdown keeps serving after reporting the
+	// server as not ready. Zero stops at once.
+	DrainDelay time.Duration
+	// ReadinessChecks are the dependencies /readyz checks by name.
+	ReadinessChecks map[string]ReadinessCheck
 }
 
-func NewServer(port string) *Server {
+func NewServer(port string, opts Options) *Server {
 	server := &Server{
-		port:   port,
-		router: mux.NewRouter(),
+		router:    mux.NewRouter(),
+		policy:    opts.Policy,
+		auditLog:  opts.AuditLog,
+		readiness: opts.ReadinessChecks,
+	}
+	server.router.Use(metrics.Middleware)
+	if opts.Authenticator != nil {
+		server.router.Use(auth.Middleware(opts.Authenticator, "/openapi.json", "/metrics", "/healthz", "/readyz"))
 	}
 	server.setupRoutes()
+	server.httpServer = &http.Server{
+		Addr:              fmt.Sprintf(":%s", port),
+		Handler:           logging.Middleware(server.router),
+		ReadHeaderTimeout: withDefault(opts.ReadTimeout, DefaultReadTimeout),
+		ReadTimeout:       withDefault(opts.ReadTimeout, DefaultReadTimeout),
+		WriteTimeout:      withDefault(opts.WriteTimeout, DefaultWriteTimeout),
+		IdleTimeout:       withDefault(opts.IdleTimeout, DefaultIdleTimeout),
+	}
+	server.drainDelay = opts.DrainDelay
+	// Requests are canceled when the client goes away, when their response
+	// can no longer be written and when Shutdown gives up on them.
+	base, cancel := context.WithCancel(context.Background())
+	server.cancel = cancel
+	server.httpServer.BaseContext = func(net.Listener) context.Context { return base }
+	server.httpServer.Handler = withDeadline(server.httpServer.Handler, server.httpServer.WriteTimeout)
 	return server
 }
 
+// withDeadline cancels the context of each request after timeout.
+func withDeadline(next http.Handler, timeout time.Duration) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx, cancel := context.WithTimeout(r.Context(), timeout)
+		defer cancel()
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+func withDefault(d, def time.Duration) time.Duration {
+	if d <= 0 {
+		return def
+	}
+	return d
+}
+
 func (s *Server) setupRoutes() {
-	api := s.router.PathPrefix("/databases").Subrouter()
-	api.HandleFunc("/list", s.ListDatabasesHandler).Methods(http.MethodPost)
-	api.HandleFunc("/create", s.CreateDatabaseHandler).Methods(http.MethodPost)
-	api.HandleFunc("/delete", s.DeleteDatabaseHandler).Methods(http.MethodPost)
-	api.HandleFunc("/exec", s.ExecSQLHandler).Methods(http.MethodPost)
+	for _, rt := range s.routes() {
+		s.router.HandleFunc(rt.Path, s.audit(rt.Operation, s.authorize(rt.Operation, rt.Handler))).Methods(http.MethodPost)
+	}
+	s.router.Handle("/mcp", s.MCP())
+	s.router.HandleFunc("/openapi.json", s.OpenAPI).Methods(http.MethodGet)
+	s.router.Handle("/metrics", metrics.Handler()).Methods(http.MethodGet)
+	s.router.HandleFunc("/healthz", s.Healthz).Methods(http.MethodGet)
+	s.router.HandleFunc("/readyz", s.Readyz).Methods(http.MethodGet)
 }
 
+// Start serves until Shutdown is called, when it returns nil.
 func (s *Server) Start() error {
-	return http.ListenAndServe(fmt.Sprintf(":%s", s.port), s.router)
+	if err := s.httpServer.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+// Shutdown reports the server as not ready and keeps serving for the drain
+// delay, so that the readiness probe fails and the endpoint is removed
+// first. It then stops accepting connections and waits for the requests in
+// flight to complete until ctx is done, when it cancels them.
+func (s *Server) Shutdown(ctx context.Context) error {
+	s.draining.Store(true)
+	timer := time.NewTimer(s.drainDelay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+	case <-ctx.Done():
+	}
+	err := s.httpServer.Shutdown(ctx)
+	s.cancel()
+	return err
 }
@@ -0,0 +1,108 @@
+// Package auth authenticates HTTP callers, carries their identity in the
+// request context and authorizes their operations.
+package auth
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strings"
+)
+
+// ErrUnauthenticated is returned when a request carries no credentials an
+// authenticator accepts.
+var ErrUnauthenticated = errors.New("authentication required")
+
+// Identity is an authenticated caller.
+type Identity struct {
+	Name   string
+	Groups []string
+}
+
+// Authenticator derives an identity from a request. It returns
+// ErrUnauthenticated when the request has no credentials it understands
+// and another error when the credentials are invalid.
+type Authenticator interface {
+	Authenticate(r *http.Request) (*Identity, error)
+}
+
+type identityKey struct{}
+
+// WithIdentity returns ctx carrying identity.
+func WithIdentity(ctx context.Context, identity *Identity) context.Context {
+	return context.WithValue(ctx, identityKey{}, identity)
+}
+
+// IdentityFrom returns the identity carried by ctx.
+func IdentityFrom(ctx context.Context) (*Identity, bool) {
+	identity, ok := ctx.Value(identityKey{}).(*Identity)
+	return identity, ok && identity != nil
+}
+
+// BearerToken returns the token of an "Authorization: Bearer" header.
+func BearerToken(r *http.Request) (string, bool) {
+	scheme, token, ok := strings.Cut(r.Header.Get("Authorization"), " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") || strings.TrimSpace(token) == "" {
+		return "", false
+	}
+	return strings.TrimSpace(token), true
+}
+
+// Chain tries authenticators in order and returns the first identity. It
+// returns ErrUnauthenticated when none understands the credentials, and
+// otherwise the first error of an authenticator that rejected them.
+func Chain(authenticators ...Authenticator) Authenticator {
+	return chain(authenticators)
+}
+
+type chain []Authenticator
+
+func (c chain) Authenticate(r *http.Request) (*Identity, error) {
+	var rejected error
+	for _, authenticator := range c {
+		identity, err := authenticator.Authenticate(r)
+		if err == nil {
+			return identity, nil
+		}
+		if !errors.Is(err, ErrUnauthenticated) && rejected == nil {
+			rejected = err
+		}
+	}
+	if rejected != nil {
+		return nil, rejected
+	}
+	return nil, ErrUnauthenticated
+}
+
+// Middleware authenticates every request except those to the public paths
+// and stores the identity in their context. Requests without valid
+// credentials are rejected with 401.
+func Middleware(authenticator Authenticator, public ...string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if slices.Contains(public, r.URL.Path) {
+				next.ServeHTTP(w, r)
+				return
+			}
+			identity, err := authenticator.Authenticate(r)
+			if errors.Is(err, ErrUnauthenticated) {
+				Unauthorized(w, "Authentication required")
+				return
+			}
+			if err != nil {
+				slog.WarnContext(r.Context(), "Authentication failed", "error", err)
+				Unauthorized(w, "Invalid credentials")
+				return
+			}
+			next.ServeHTTP(w, r.WithContext(WithIdentity(r.Context(), identity)))
+		})
+	}
+}
+
+// Unauthorized writes a 401 response.
+func Unauthorized(w http.ResponseWriter, message string) {
+	w.Header().Set("WWW-Authenticate", "Bearer")
+	http.Error(w, message, http.StatusUnauthorized)
+}
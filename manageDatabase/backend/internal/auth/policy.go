@@ -0,0 +1,85 @@
+package auth
+
+import (
+	"fmt"
+	"os"
+	"sigs.k8s.io/yaml"
+	"slices"
+)
+
+// Wildcard matches any user, group or operation in a policy.
+const Wildcard = "*"
+
+// Policy maps identities to the operations they may perform. A request is
+// allowed when a rule matches the user or one of its groups and the
+// operation; anything not allowed is denied.
+type Policy struct {
+	Rules []PolicyRule `json:"rules"`
+}
+
+// PolicyRule grants Operations to Users and Groups. Operations are the
+// names of the MCP tools, such as exec_sql.
+type PolicyRule struct {
+	Users      []string `json:"users,omitempty"`
+	Groups     []string `json:"groups,omitempty"`
+	Operations []string `json:"operations"`
+}
+
+// LoadPolicy reads a policy file in YAML or JSON.
+func LoadPolicy(path string) (*Policy, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read policy: %w", err)
+	}
+	var policy Policy
+	if err := yaml.UnmarshalStrict(data, &policy); err != nil {
+		return nil, fmt.Errorf("failed to parse policy %s: %w", path, err)
+	}
+	for i, rule := range policy.Rules {
+		if len(rule.Users) == 0 && len(rule.Groups) == 0 {
+			return nil, fmt.Errorf("rule %d of policy %s has no users or groups", i, path)
+		}
+		if len(rule.Operations) == 0 {
+			return nil, fmt.Errorf("rule %d of policy %s has no operations", i, path)
+		}
+	}
+	return &policy, nil
+}
+
+// Allows reports whether identity may perform operation.
+func (p *Policy) Allows(identity *Identity, operation string) bool {
+	for _, rule := range p.Rules {
+		if rule.matchesIdentity(identity) && matches(rule.Operations, operation) {
+			return true
+		}
+	}
+	return false
+}
+
+// Grants reports whether a rule names operation for identity. Unlike
+// Allows, a wildcard operation does not count, so operations that expose
+// data across callers must be granted explicitly.
+func (p *Policy) Grants(identity *Identity, operation string) bool {
+	for _, rule := range p.Rules {
+		if rule.matchesIdentity(identity) && slices.Contains(rule.Operations, operation) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r PolicyRule) matchesIdentity(identity *Identity) bool {
+	if matches(r.Users, identity.Name) {
+		return true
+	}
+	for _, group := range identity.Groups {
+		if matches(r.Groups, group) {
+			return true
+		}
+	}
+	return false
+}
+
+func matches(values []string, value string) bool {
+	return slices.Contains(values, Wildcard) || (value != "" && slices.Contains(values, value))
+}
@@ -0,0 +1,126 @@
+package auth
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// serviceAccountDir holds the credentials Kubernetes mounts into pods.
+const serviceAccountDir = "/var/run/secrets/kubernetes.io/serviceaccount"
+
+// TokenReviewAuthenticator validates bearer tokens with the TokenReview API
+// of the Kubernetes cluster the service runs in, using the credentials of
+// its service account.
+type TokenReviewAuthenticator struct {
+	server string
+	client *http.Client
+}
+
+// NewInClusterTokenReviewAuthenticator reads the address of the API server
+// from the environment and its CA from the service account mount.
+func NewInClusterTokenReviewAuthenticator() (*TokenReviewAuthenticator, error) {
+	host, port := os.Getenv("KUBERNETES_SERVICE_HOST"), os.Getenv("KUBERNETES_SERVICE_PORT")
+	if host == "" || port == "" {
+		return nil, fmt.Errorf("KUBERNETES_SERVICE_HOST and KUBERNETES_SERVICE_PORT are not set")
+	}
+	ca, err := os.ReadFile(serviceAccountDir + "/ca.crt")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the cluster CA: %w", err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(ca) {
+		return nil, fmt.Errorf("invalid cluster CA")
+	}
+	return &TokenReviewAuthenticator{
+		server: "https://" + net.JoinHostPort(host, port),
+		client: &http.Client{
+			Timeout:   10 * time.Second,
+			Transport: &http.Transport{TLSClientConfig: &tls.Config{RootCAs: pool}},
+		},
+	}, nil
+}
+
+type tokenReview struct {
+	APIVersion string `json:"apiVersion"`
+	Kind       string `json:"kind"`
+	Spec       struct {
+		Token string `json:"token"`
+	} `json:"spec"`
+	Status struct {
+		Authenticated bool   `json:"authenticated"`
+		Error         string `json:"error"`
+		User          struct {
+			Username string   `json:"username"`
+			Groups   []string `json:"groups"`
+		} `json:"user"`
+	} `json:"status"`
+}
+
+func (a *TokenReviewAuthenticator) Authenticate(r *http.Request) (*Identity, error) {
+	token, ok := BearerToken(r)
+	if !ok {
+		return nil, ErrUnauthenticated
+	}
+	review := tokenReview{APIVersion: "authentication.k8s.io/v1", Kind: "TokenReview"}
+	review.Spec.Token = token
+	body, err := json.Marshal(review)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := a.do(r.Context(), http.MethodPost, "/apis/authentication.k8s.io/v1/tokenreviews", body)
+	if err != nil {
+		return nil, fmt.Errorf("token review failed: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusCreated && resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("token review failed: %s", resp.Status)
+	}
+	var result tokenReview
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("token review failed: %w", err)
+	}
+	if !result.Status.Authenticated {
+		return nil, fmt.Errorf("token rejected: %s", result.Status.Error)
+	}
+	return &Identity{Name: result.Status.User.Username, Groups: result.Status.User.Groups}, nil
+}
+
+// Ping checks that the API server is reachable and ready.
+func (a *TokenReviewAuthenticator) Ping(ctx context.Context) error {
+	resp, err := a.do(ctx, http.MethodGet, "/readyz", nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("API server is not ready: %s", resp.Status)
+	}
+	return nil
+}
+
+// do sends a request to the API server as the service account.
+func (a *TokenReviewAuthenticator) do(ctx context.Context, method, path string, body []byte) (*http.Response, error) {
+	// The service account token is read on every request as it is rotated.
+	serviceToken, err := os.ReadFile(serviceAccountDir + "/token")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the service account token: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, a.server+path, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	req.Header.Set("Authorization", "Bearer "+strings.TrimSpace(string(serviceToken)))
+	return a.client.Do(req)
+}
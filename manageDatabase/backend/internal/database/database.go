@@ -1,26 +1,29 @@
 package database
 
 import (
-	"database/sql"
+	"context"
 	"fmt"
 	_ "github.com/go-sql-driver/mysql"
-	"log"
+	"log/slog"
 	"strings"
 )
 
+// Drivers are the values accepted as the type of a request.
+var Drivers = []string{"mysql", "postgres"}
+
 // CreateDatabase creates a database if it does not exist.
 // Supports both MySQL and PostgreSQL.
-func CreateDatabase(driver, dsn, dbName string) error {
+func CreateDatabase(ctx context.Context, driver, dsn, dbName string) error {
 	// Basic validation to avoid SQL injection
 	if strings.ContainsAny(dbName, " ;'\"") {
 		return fmt.Errorf("invalid database name: %s", dbName)
 	}
 	// Connect to database server (without a specific DB selected)
-	db, err := sql.Open(driver, dsn)
+	db, closeDB, err := open(driver, dsn)
 	if err != nil {
 		return fmt.Errorf("failed to connect to database server: %v", err)
 	}
-	defer db.Close()
+	defer closeDB()
 	var createSQL string
 	switch driver {
 	case "mysql":
@@ -32,12 +35,12 @@ func CreateDatabase(driver, dsn, dbName string) error {
 		// Check if database already exists
 		var exists bool
 		checkSQL := "SELECT EXISTS(SELECT 1 FROM pg_database WHERE datname = $1);"
-		err = db.QueryRow(checkSQL, dbName).Scan(&exists)
+		err = queryRow(ctx, db, driver, checkSQL, []any{dbName}, &exists)
 		if err != nil {
 			return fmt.Errorf("failed to check database existence: %v", err)
 		}
 		if exists {
-			log.Printf("Database %s already exists", dbName)
+			slog.Info("Database already exists", "name", dbName)
 			return nil
 		}
 		// PostgreSQL CREATE DATABASE does not support IF NOT EXISTS before version 9.1+
@@ -45,22 +48,22 @@ func CreateDatabase(driver, dsn, dbName string) error {
 	default:
 		return fmt.Errorf("unsupported driver: %s", driver)
 	}
-	log.Printf("Executing SQL: %s", createSQL)
-	if _, err = db.Exec(createSQL); err != nil {
+	slog.Debug("Executing SQL", "sql", createSQL)
+	if _, err = execSQL(ctx, db, driver, createSQL); err != nil {
 		return fmt.Errorf("failed to create database: %v", err)
 	}
-	log.Printf("Database %s created successfully", dbName)
+	slog.Info("Created database", "name", dbName)
 	return nil
 }
 
 // ListDatabases returns a list of databases for MySQL or PostgreSQL based on the driver type.
-func ListDatabases(driver, dsn string) ([]string, error) {
+func ListDatabases(ctx context.Context, driver, dsn string) ([]string, error) {
 	// Connect to the database server (without selecting a specific database)
-	db, err := sql.Open(driver, dsn)
+	db, closeDB, err := open(driver, dsn)
 	if err != nil {
 		return nil, fmt.Errorf("failed to connect to database server: %v", err)
 	}
-	defer db.Close()
+	defer closeDB()
 	var query string
 	switch driver {
 	case "mysql":
@@ -75,7 +78,7 @@ func ListDatabases(driver, dsn string) ([]string, error) {
 	default:
 		return nil, fmt.Errorf("unsupported driver: %s", driver)
 	}
-	rows, err := db.Query(query)
+	rows, err := querySQL(ctx, db, driver, query)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query database list: %v", err)
 	}
@@ -92,15 +95,15 @@ func ListDatabases(driver, dsn string) ([]string, error) {
 	return databases, nil
 }
 
-func DeleteDatabase(driver, dsn, dbName string) error {
+func DeleteDatabase(ctx context.Context, driver, dsn, dbName string) error {
 	if strings.ContainsAny(dbName, " ;'\"") {
 		return fmt.Errorf("invalid database name: %s", dbName)
 	}
-	db, err := sql.Open(driver, dsn)
+	db, closeDB, err := open(driver, dsn)
 	if err != nil {
 		return fmt.Errorf("failed to connect to database server: %v", err)
 	}
-	defer db.Close()
+	defer closeDB()
 	var dropSQL string
 	switch driver {
 	case "mysql":
@@ -110,24 +113,24 @@ func DeleteDatabase(driver, dsn, dbName string) error {
 	default:
 		return fmt.Errorf("unsupported driver: %s", driver)
 	}
-	if _, err := db.Exec(dropSQL); err != nil {
+	if _, err := execSQL(ctx, db, driver, dropSQL); err != nil {
 		return fmt.Errorf("failed to drop database: %v", err)
 	}
 	return nil
 }
 
-func ExecSQL(driver, dsn, sqlStmt string) (string, error) {
+func ExecSQL(ctx context.Context, driver, dsn, sqlStmt string) (string, error) {
 	if strings.TrimSpace(sqlStmt) == "" {
 		return "", fmt.Errorf("SQL statement is empty")
 	}
-	db, err := sql.Open(driver, dsn)
+	db, closeDB, err := open(driver, dsn)
 	if err != nil {
 		return "", fmt.Errorf("failed to connect to database server: %v", err)
 	}
-	defer db.Close()
-	res, err := db.Exec(sqlStmt)
+	defer closeDB()
+	res, err := execSQL(ctx, db, driver, sqlStmt)
 	if err != nil {
-		return "", fmt.Errorf("failed to execute SQL: %v", err)
+		return "", fmt.Errorf("failed to execute SQL on %s server %s: %w", driver, ServerAddress(driver, dsn), err)
 	}
 	affected, _ := res.RowsAffected()
 	return fmt.Sprintf("%d rows affected", affected), nil
@@ -0,0 +1,79 @@
+package database
+
+import (
+	"github.com/go-sql-driver/mysql"
+	"net"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// redactedPassword replaces passwords in redacted DSNs.
+const redactedPassword = "REDACTED"
+
+var (
+	pgPassword = regexp.MustCompile(`(password\s*=\s*)('(?:[^'\\]|\\.)*'|\S+)`)
+	pgHost     = regexp.MustCompile(`\bhost\s*=\s*(\S+)`)
+	pgPort     = regexp.MustCompile(`\bport\s*=\s*(\S+)`)
+)
+
+// RedactDSN returns dsn with its password replaced, so it can be logged.
+// A DSN that cannot be parsed is redacted entirely.
+func RedactDSN(driver, dsn string) string {
+	switch driver {
+	case "mysql":
+		cfg, err := mysql.ParseDSN(dsn)
+		if err != nil {
+			return redactedPassword
+		}
+		if cfg.Passwd != "" {
+			cfg.Passwd = redactedPassword
+		}
+		return cfg.FormatDSN()
+	case "postgres":
+		if u, err := url.Parse(dsn); err == nil && (u.Scheme == "postgres" || u.Scheme == "postgresql") {
+			if _, ok := u.User.Password(); ok {
+				u.User = url.UserPassword(u.User.Username(), redactedPassword)
+			}
+			query := u.Query()
+			if query.Has("password") {
+				query.Set("password", redactedPassword)
+				u.RawQuery = query.Encode()
+			}
+			return u.String()
+		}
+		return pgPassword.ReplaceAllString(dsn, "${1}"+redactedPassword)
+	default:
+		return redactedPassword
+	}
+}
+
+// ServerAddress returns the host and port of the server dsn connects to,
+// or an empty string when it cannot be told.
+func ServerAddress(driver, dsn string) string {
+	switch driver {
+	case "mysql":
+		cfg, err := mysql.ParseDSN(dsn)
+		if err != nil {
+			return ""
+		}
+		return cfg.Addr
+	case "postgres":
+		if u, err := url.Parse(dsn); err == nil && (u.Scheme == "postgres" || u.Scheme == "postgresql") {
+			return u.Host
+		}
+		host, port := "", ""
+		if m := pgHost.FindStringSubmatch(dsn); m != nil {
+			host = strings.Trim(m[1], "'")
+		}
+		if m := pgPort.FindStringSubmatch(dsn); m != nil {
+			port = strings.Trim(m[1], "'")
+		}
+		if host != "" && port != "" {
+			return net.JoinHostPort(host, port)
+		}
+		return host
+	default:
+		return ""
+	}
+}
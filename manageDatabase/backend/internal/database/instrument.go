@@ -0,0 +1,53 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"manageDatabase/internal/metrics"
+	"slices"
+	"time"
+)
+
+// engine is the metrics label of driver, bounded to the supported drivers.
+func engine(driver string) string {
+	if slices.Contains(Drivers, driver) {
+		return driver
+	}
+	return "other"
+}
+
+// open opens a database handle whose pool is reported in the metrics
+// until it is closed with the returned function.
+func open(driver, dsn string) (*sql.DB, func(), error) {
+	db, err := sql.Open(driver, dsn)
+	if err != nil {
+		return nil, nil, err
+	}
+	untrack := metrics.TrackPool(engine(driver), db)
+	return db, func() {
+		untrack()
+		db.Close()
+	}, nil
+}
+
+func execSQL(ctx context.Context, db *sql.DB, driver, query string, args ...any) (sql.Result, error) {
+	start := time.Now()
+	res, err := db.ExecContext(ctx, query, args...)
+	metrics.ObserveSQL(engine(driver), "exec", start, err)
+	return res, err
+}
+
+// querySQL measures the time until the first rows are available.
+func querySQL(ctx context.Context, db *sql.DB, driver, query string, args ...any) (*sql.Rows, error) {
+	start := time.Now()
+	rows, err := db.QueryContext(ctx, query, args...)
+	metrics.ObserveSQL(engine(driver), "query", start, err)
+	return rows, err
+}
+
+func queryRow(ctx context.Context, db *sql.DB, driver, query string, args []any, dest ...any) error {
+	start := time.Now()
+	err := db.QueryRowContext(ctx, query, args...).Scan(dest...)
+	metrics.ObserveSQL(engine(driver), "query", start, err)
+	return err
+}
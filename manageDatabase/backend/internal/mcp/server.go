@@ -0,0 +1,271 @@
+// Package mcp serves HTTP handlers as Model Context Protocol tools over
+// Streamable HTTP and stdio.
+package mcp
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+)
+
+// ProtocolVersion is the latest MCP revision this server implements.
+const ProtocolVersion = "2025-03-26"
+
+// supportedVersions are the revisions accepted from clients during
+// initialization.
+var supportedVersions = []string{ProtocolVersion, "2024-11-05"}
+
+// JSON-RPC error codes.
+const (
+	codeParseError     = -32700
+	codeInvalidRequest = -32600
+	codeMethodNotFound = -32601
+	codeInvalidParams  = -32602
+)
+
+// Tool exposes an HTTP handler as an MCP tool. The tool arguments are sent
+// to the handler as the JSON request body, so the input schema is generated
+// from the request struct the handler decodes unless InputSchema is set.
+type Tool struct {
+	Name        string
+	Description string
+	Request     interface{}
+	InputSchema map[string]interface{}
+	Handler     http.HandlerFunc
+}
+
+type Server struct {
+	name    string
+	version string
+	tools   []Tool
+}
+
+func NewServer(name, version string, tools []Tool) *Server {
+	return &Server{name: name, version: version, tools: tools}
+}
+
+type request struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params,omitempty"`
+}
+
+type response struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  interface{}     `json:"result,omitempty"`
+	Error   *rpcError       `json:"error,omitempty"`
+}
+
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+type toolContent struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+}
+
+type toolResult struct {
+	Content []toolContent `json:"content"`
+	IsError bool          `json:"isError"`
+}
+
+// ServeHTTP implements the Streamable HTTP transport. Each POST carries a
+// JSON-RPC message or batch and is answered with a JSON body; notifications
+// are acknowledged with 202. The server sends no unsolicited messages, so
+// it does not offer an SSE stream on GET.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "Only POST method is allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, "Failed to read request body", http.StatusBadRequest)
+		return
+	}
+	reply := s.handleMessage(r.Context(), body)
+	if reply == nil {
+		w.WriteHeader(http.StatusAccepted)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(reply)
+}
+
+// ServeStdio implements the stdio transport: newline-delimited JSON-RPC
+// messages on in, answers on out. It returns when in is exhausted or ctx is
+// done.
+func (s *Server) ServeStdio(ctx context.Context, in io.Reader, out io.Writer) error {
+	scanner := bufio.NewScanner(in)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		reply := s.handleMessage(ctx, line)
+		if reply == nil {
+			continue
+		}
+		if _, err := out.Write(append(reply, '\n')); err != nil {
+			return err
+		}
+	}
+	return scanner.Err()
+}
+
+// handleMessage answers a JSON-RPC message or batch, returning nil when
+// nothing needs to be sent back.
+func (s *Server) handleMessage(ctx context.Context, message []byte) []byte {
+	if len(message) > 0 && message[0] == '[' {
+		var batch []json.RawMessage
+		if err := json.Unmarshal(message, &batch); err != nil {
+			return encode(errorResponse(nil, codeParseError, "Parse error"))
+		}
+		var replies []response
+		for _, raw := range batch {
+			if reply := s.handleRequest(ctx, raw); reply != nil {
+				replies = append(replies, *reply)
+			}
+		}
+		if len(replies) == 0 {
+			return nil
+		}
+		return encode(replies)
+	}
+	reply := s.handleRequest(ctx, message)
+	if reply == nil {
+		return nil
+	}
+	return encode(reply)
+}
+
+func (s *Server) handleRequest(ctx context.Context, raw json.RawMessage) *response {
+	var req request
+	if err := json.Unmarshal(raw, &req); err != nil {
+		return errorResponse(nil, codeParseError, "Parse error")
+	}
+	if req.JSONRPC != "2.0" || req.Method == "" {
+		return errorResponse(req.ID, codeInvalidRequest, "Invalid request")
+	}
+	// Notifications and responses to server requests need no answer.
+	if len(req.ID) == 0 {
+		return nil
+	}
+	switch req.Method {
+	case "initialize":
+		return s.initialize(req)
+	case "ping":
+		return &response{JSONRPC: "2.0", ID: req.ID, Result: map[string]interface{}{}}
+	case "tools/list":
+		return s.listTools(req)
+	case "tools/call":
+		return s.callTool(ctx, req)
+	default:
+		return errorResponse(req.ID, codeMethodNotFound, "Method not found: "+req.Method)
+	}
+}
+
+func (s *Server) initialize(req request) *response {
+	var params struct {
+		ProtocolVersion string `json:"protocolVersion"`
+	}
+	if len(req.Params) > 0 {
+		if err := json.Unmarshal(req.Params, &params); err != nil {
+			return errorResponse(req.ID, codeInvalidParams, "Invalid params")
+		}
+	}
+	version := ProtocolVersion
+	for _, v := range supportedVersions {
+		if v == params.ProtocolVersion {
+			version = v
+		}
+	}
+	return &response{JSONRPC: "2.0", ID: req.ID, Result: map[string]interface{}{
+		"protocolVersion": version,
+		"capabilities": map[string]interface{}{
+			"tools": map[string]interface{}{},
+		},
+		"serverInfo": map[string]interface{}{
+			"name":    s.name,
+			"version": s.version,
+		},
+	}}
+}
+
+func (s *Server) listTools(req request) *response {
+	tools := make([]map[string]interface{}, 0, len(s.tools))
+	for _, tool := range s.tools {
+		schema := tool.InputSchema
+		if schema == nil {
+			schema = SchemaFor(tool.Request)
+		}
+		tools = append(tools, map[string]interface{}{
+			"name":        tool.Name,
+			"description": tool.Description,
+			"inputSchema": schema,
+		})
+	}
+	return &response{JSONRPC: "2.0", ID: req.ID, Result: map[string]interface{}{"tools": tools}}
+}
+
+// callTool runs the handler of a tool with the arguments as request body.
+// The response body becomes the text content, and error statuses are
+// reported as tool errors so the model can see and correct them.
+func (s *Server) callTool(ctx context.Context, req request) *response {
+	var params struct {
+		Name      string          `json:"name"`
+		Arguments json.RawMessage `json:"arguments"`
+	}
+	if err := json.Unmarshal(req.Params, &params); err != nil {
+		return errorResponse(req.ID, codeInvalidParams, "Invalid params")
+	}
+	var tool *Tool
+	for i := range s.tools {
+		if s.tools[i].Name == params.Name {
+			tool = &s.tools[i]
+		}
+	}
+	if tool == nil {
+		return errorResponse(req.ID, codeInvalidParams, "Unknown tool: "+params.Name)
+	}
+	arguments := params.Arguments
+	if len(arguments) == 0 || string(arguments) == "null" {
+		arguments = json.RawMessage("{}")
+	}
+	httpReq := httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(arguments)).WithContext(ctx)
+	httpReq.Header.Set("Content-Type", "application/json")
+	recorder := httptest.NewRecorder()
+	tool.Handler(recorder, httpReq)
+	return &response{JSONRPC: "2.0", ID: req.ID, Result: toolResult{
+		Content: []toolContent{{Type: "text", Text: recorder.Body.String()}},
+		IsError: recorder.Code >= http.StatusBadRequest,
+	}}
+}
+
+func errorResponse(id json.RawMessage, code int, message string) *response {
+	if len(id) == 0 {
+		id = json.RawMessage("null")
+	}
+	return &response{JSONRPC: "2.0", ID: id, Error: &rpcError{Code: code, Message: message}}
+}
+
+func encode(v interface{}) []byte {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return []byte(`{"jsonrpc":"2.0","id":null,"error":{"code":-32603,"message":"Internal error"}}`)
+	}
+	return data
+}
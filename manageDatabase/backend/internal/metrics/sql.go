@@ -0,0 +1,128 @@
+package metrics
+
+import (
+	"database/sql"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"sync"
+	"time"
+)
+
+var (
+	sqlDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "sql_duration_seconds",
+		Help:      "Duration of SQL statements by engine and operation, exec or query.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"engine", "operation"})
+	sqlErrors = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "sql_errors_total",
+		Help:      "Failed SQL statements by engine and operation, exec or query.",
+	}, []string{"engine", "operation"})
+)
+
+// ObserveSQL records a statement of engine started at start.
+func ObserveSQL(engine, operation string, start time.Time, err error) {
+	sqlDuration.WithLabelValues(engine, operation).Observe(time.Since(start).Seconds())
+	if err != nil {
+		sqlErrors.WithLabelValues(engine, operation).Inc()
+	}
+}
+
+// pools reports the connection pools of the open database handles, summed
+// per engine. Statistics of closed handles are kept so counters never go
+// down.
+var pools = newPoolCollector()
+
+func init() {
+	prometheus.MustRegister(pools)
+}
+
+// TrackPool reports the pool of db until the returned function is called,
+// which must happen before db is closed.
+func TrackPool(engine string, db *sql.DB) func() {
+	pools.mu.Lock()
+	defer pools.mu.Unlock()
+	pools.open[db] = engine
+	return func() {
+		pools.mu.Lock()
+		defer pools.mu.Unlock()
+		stats := db.Stats()
+		closed := pools.closed[engine]
+		closed.WaitCount += stats.WaitCount
+		closed.WaitDuration += stats.WaitDuration
+		pools.closed[engine] = closed
+		delete(pools.open, db)
+	}
+}
+
+type poolCollector struct {
+	mu     sync.Mutex
+	open   map[*sql.DB]string
+	closed map[string]sql.DBStats
+
+	pools        *prometheus.Desc
+	openConns    *prometheus.Desc
+	inUse        *prometheus.Desc
+	idle         *prometheus.Desc
+	waitCount    *prometheus.Desc
+	waitDuration *prometheus.Desc
+}
+
+func newPoolCollector() *poolCollector {
+	desc := func(name, help string) *prometheus.Desc {
+		return prometheus.NewDesc(prometheus.BuildFQName(namespace, "db_pool", name), help, []string{"engine"}, nil)
+	}
+	return &poolCollector{
+		open:         map[*sql.DB]string{},
+		closed:       map[string]sql.DBStats{},
+		pools:        desc("open_pools", "Open database handles."),
+		openConns:    desc("open_connections", "Established connections, in use and idle."),
+		inUse:        desc("in_use_connections", "Connections in use."),
+		idle:         desc("idle_connections", "Idle connections."),
+		waitCount:    desc("wait_total", "Times a connection was waited for."),
+		waitDuration: desc("wait_seconds_total", "Time spent waiting for connections."),
+	}
+}
+
+func (c *poolCollector) Describe(ch chan<- *prometheus.Desc) {
+	for _, desc := range []*prometheus.Desc{c.pools, c.openConns, c.inUse, c.idle, c.waitCount, c.waitDuration} {
+		ch <- desc
+	}
+}
+
+func (c *poolCollector) Collect(ch chan<- prometheus.Metric) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	type totals struct {
+		pools int
+		stats sql.DBStats
+	}
+	byEngine := map[string]*totals{}
+	for engine, stats := range c.closed {
+		byEngine[engine] = &totals{stats: sql.DBStats{WaitCount: stats.WaitCount, WaitDuration: stats.WaitDuration}}
+	}
+	for db, engine := range c.open {
+		t, ok := byEngine[engine]
+		if !ok {
+			t = &totals{}
+			byEngine[engine] = t
+		}
+		stats := db.Stats()
+		t.pools++
+		t.stats.OpenConnections += stats.OpenConnections
+		t.stats.InUse += stats.InUse
+		t.stats.Idle += stats.Idle
+		t.stats.WaitCount += stats.WaitCount
+		t.stats.WaitDuration += stats.WaitDuration
+	}
+	for engine, t := range byEngine {
+		ch <- prometheus.MustNewConstMetric(c.pools, prometheus.GaugeValue, float64(t.pools), engine)
+		ch <- prometheus.MustNewConstMetric(c.openConns, prometheus.GaugeValue, float64(t.stats.OpenConnections), engine)
+		ch <- prometheus.MustNewConstMetric(c.inUse, prometheus.GaugeValue, float64(t.stats.InUse), engine)
+		ch <- prometheus.MustNewConstMetric(c.idle, prometheus.GaugeValue, float64(t.stats.Idle), engine)
+		ch <- prometheus.MustNewConstMetric(c.waitCount, prometheus.CounterValue, float64(t.stats.WaitCount), engine)
+		ch <- prometheus.MustNewConstMetric(c.waitDuration, prometheus.CounterValue, t.stats.WaitDuration.Seconds(), engine)
+	}
+}
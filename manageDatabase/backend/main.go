@@ -1,15 +1,178 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
 	"manageDatabase/internal/api"
+	"manageDatabase/internal/audit"
+	"manageDatabase/internal/auth"
+	"manageDatabase/internal/logging"
 	"os"
+	"os/signal"
+	"syscall"
+	"time"
 )
 
 func main() {
-	port := os.Getenv("SERVER_PORT")
-	if port == "" {
-		port = "8080"
+	var port string
+	var stdio bool
+	var apiKeys, jwks, jwtIssuer, jwtAudience, policyFile string
+	var tokenReview bool
+	var auditFile, auditWebhook string
+	var auditMaxSize, auditMaxBackups, auditRecent int
+	var logLevel string
+	var readTimeout, writeTimeout, idleTimeout, drainDelay, shutdownTimeout time.Duration
+	flag.StringVar(&port, "port", "8080", "Port for the API server")
+	flag.BoolVar(&stdio, "stdio", false, "Serve MCP over stdin and stdout instead of HTTP")
+	flag.StringVar(&apiKeys, "api-keys", "", "YAML or JSON file of the static API keys accepted as X-API-Key or bearer tokens")
+	flag.StringVar(&jwks, "jwks", "", "JWKS file of the keys that sign accepted bearer JWTs")
+	flag.StringVar(&jwtIssuer, "jwt-issuer", "", "Issuer required in bearer JWTs")
+	flag.StringVar(&jwtAudience, "jwt-audience", "", "Audience required in bearer JWTs")
+	flag.BoolVar(&tokenReview, "token-review", false, "Accept Kubernetes bearer tokens validated by TokenReview")
+	flag.StringVar(&policyFile, "auth-policy", "", "YAML or JSON file mapping callers to the operations they may use")
+	flag.StringVar(&auditFile, "audit-file", "", "File the audit events are appended to as JSON lines")
+	flag.IntVar(&auditMaxSize, "audit-max-size", audit.DefaultMaxFileSize>>20, "Size in MiB at which the audit file is rotated")
+	flag.IntVar(&auditMaxBackups, "audit-max-backups", audit.DefaultMaxBackups, "Number of rotated audit files kept")
+	flag.StringVar(&auditWebhook, "audit-webhook", "", "URL every audit event is posted to")
+	flag.IntVar(&auditRecent, "audit-recent", audit.DefaultRecent, "Number of recent audit events kept for /audit/events")
+	flag.StringVar(&logLevel, "log-level", "info", "Minimum level of logged messages: debug, info, warn or error")
+	flag.DurationVar(&readTimeout, "read-timeout", api.DefaultReadTimeout, "Maximum duration of reading a request")
+	flag.DurationVar(&writeTimeout, "write-timeout", api.DefaultWriteTimeout, "Maximum duration of handling a request and writing its response")
+	flag.DurationVar(&idleTimeout, "idle-timeout", api.DefaultIdleTimeout, "How long an idle keep-alive connection stays open")
+	flag.DurationVar(&drainDelay, "drain-delay", api.DefaultDrainDelay, "How long to keep serving on SIGTERM after failing the readiness check")
+	flag.DurationVar(&shutdownTimeout, "shutdown-timeout", 25*time.Second, "How long requests in flight may take to complete on SIGTERM")
+	flag.Parse()
+	if envLevel := os.Getenv("LOG_LEVEL"); envLevel != "" {
+		logLevel = envLevel
 	}
-	server := api.NewServer(port)
-	server.Start()
+	level, err := logging.ParseLevel(logLevel)
+	if err != nil {
+		fatal(err)
+	}
+	slog.SetDefault(logging.New(os.Stderr, level))
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	if envKeys := os.Getenv("API_KEYS_FILE"); envKeys != "" {
+		apiKeys = envKeys
+	}
+	if envJWKS := os.Getenv("JWKS_FILE"); envJWKS != "" {
+		jwks = envJWKS
+	}
+	if envIssuer := os.Getenv("JWT_ISSUER"); envIssuer != "" {
+		jwtIssuer = envIssuer
+	}
+	if envAudience := os.Getenv("JWT_AUDIENCE"); envAudience != "" {
+		jwtAudience = envAudience
+	}
+	if os.Getenv("TOKEN_REVIEW") == "true" {
+		tokenReview = true
+	}
+	if envPolicy := os.Getenv("AUTH_POLICY_FILE"); envPolicy != "" {
+		policyFile = envPolicy
+	}
+	if envAudit := os.Getenv("AUDIT_FILE"); envAudit != "" {
+		auditFile = envAudit
+	}
+	if envWebhook := os.Getenv("AUDIT_WEBHOOK_URL"); envWebhook != "" {
+		auditWebhook = envWebhook
+	}
+	if err := api.ValidateOpenAPI(); err != nil {
+		fatal(err)
+	}
+	if envPort := os.Getenv("SERVER_PORT"); envPort != "" {
+		port = envPort
+	}
+	var sinks []audit.Sink
+	if auditFile != "" {
+		sink, err := audit.NewFileSink(auditFile, int64(auditMaxSize)<<20, auditMaxBackups)
+		if err != nil {
+			fatal(err)
+		}
+		sinks = append(sinks, sink)
+	}
+	if auditWebhook != "" {
+		sinks = append(sinks, audit.NewWebhookSink(auditWebhook))
+	}
+	auditLog := audit.NewLogger(auditRecent, sinks...)
+	defer auditLog.Close()
+	opts := api.Options{
+		AuditLog:        auditLog,
+		ReadTimeout:     readTimeout,
+		WriteTimeout:    writeTimeout,
+		IdleTimeout:     idleTimeout,
+		DrainDelay:      drainDelay,
+		ReadinessChecks: map[string]api.ReadinessCheck{},
+	}
+	var authenticators []auth.Authenticator
+	if apiKeys != "" {
+		authenticator, err := auth.LoadAPIKeys(apiKeys)
+		if err != nil {
+			fatal(err)
+		}
+		authenticators = append(authenticators, authenticator)
+	}
+	if jwks != "" {
+		authenticator, err := auth.NewJWTAuthenticator(jwks, jwtIssuer, jwtAudience)
+		if err != nil {
+			fatal(err)
+		}
+		authenticators = append(authenticators, authenticator)
+	}
+	if tokenReview {
+		authenticator, err := auth.NewInClusterTokenReviewAuthenticator()
+		if err != nil {
+			fatal(fmt.Errorf("TokenReview must run in a cluster: %w", err))
+		}
+		authenticators = append(authenticators, authenticator)
+		opts.ReadinessChecks["kubernetes"] = authenticator.Ping
+	}
+	if len(authenticators) > 0 {
+		opts.Authenticator = auth.Chain(authenticators...)
+	}
+	if policyFile != "" {
+		if opts.Authenticator == nil {
+			fatal(errors.New("an authorization policy needs -api-keys, -jwks or -token-review to identify callers"))
+		}
+		policy, err := auth.LoadPolicy(policyFile)
+		if err != nil {
+			fatal(err)
+		}
+		// MCP over stdio serves the local user who started the process.
+		if !stdio {
+			opts.Policy = policy
+		}
+	}
+	server := api.NewServer(port, opts)
+	if stdio {
+		if err := server.MCP().ServeStdio(ctx, os.Stdin, os.Stdout); err != nil {
+			fatal(err)
+		}
+		return
+	}
+	slog.Info("Starting server", "port", port)
+	errs := make(chan error, 1)
+	go func() { errs <- server.Start() }()
+	select {
+	case err := <-errs:
+		if err != nil {
+			fatal(err)
+		}
+	case <-ctx.Done():
+		stop()
+		slog.Info("Shutting down server", "drain_delay", drainDelay.String(), "timeout", shutdownTimeout.String())
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), drainDelay+shutdownTimeout)
+		defer cancel()
+		if err := server.Shutdown(shutdownCtx); err != nil {
+			slog.Error("Failed to drain requests", "error", err)
+		}
+	}
+}
+
+// fatal logs err and exits.
+func fatal(err error) {
+	slog.Error(err.Error())
+	os.Exit(1)
 }
@@ -22,3 +22,25 @@ type ExecSQLRequest struct {
 	DSN  string `json:"dsn"`
 	SQL  string `json:"sql"`
 }
+
+type AuditEvent struct {
+	Time       string                 `json:"time"`
+	Operation  string                 `json:"operation"`
+	Identity   string                 `json:"identity,omitempty"`
+	Groups     []string               `json:"groups,omitempty"`
+	Target     string                 `json:"target,omitempty"`
+	Params     map[string]interface{} `json:"params,omitempty"`
+	Outcome    string                 `json:"outcome"`
+	Status     int                    `json:"status"`
+	Error      string                 `json:"error,omitempty"`
+	DurationMs int64                  `json:"duration_ms"`
+}
+
+type ListAuditEventsRequest struct {
+	Operation string `json:"operation,omitempty"`
+	Identity  string `json:"identity,omitempty"`
+	Target    string `json:"target,omitempty"`
+	Outcome   string `json:"outcome,omitempty"`
+	Since     string `json:"since,omitempty"`
+	Limit     int    `json:"limit,omitempty"`
+}